@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+// runKeys manages the api keys of the db, the server must be stopped as the
+// db is locked while it runs
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: keys add|list")
+	}
+
+	switch args[0] {
+	case "add":
+		return addKey(args[1:])
+	case "list":
+		return listKeys()
+	default:
+		return fmt.Errorf("unknown keys command: %s", args[0])
+	}
+}
+
+func addKey(args []string) error {
+	fs := flag.NewFlagSet("keys add", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the key")
+	scopes := fs.String("scopes", data.ScopeRead, "comma separated scopes: "+strings.Join(data.Scopes, ", "))
+	addrs := fs.String("addresses", "", "comma separated address patterns the key may read & write, e.g. 0xabc*, empty allows all")
+	subject := fs.String("cert-subject", "", "client certificate subject of mutual tls, e.g. CN=edge-01,O=Operator")
+	expires := fs.Duration("expires", 0, "lifetime of the key, e.g. 720h, 0 never expires")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	k := data.NewAPIKey()
+	k.Name = *name
+	k.Scopes = strings.Split(*scopes, ",")
+	k.Subject = *subject
+	if *addrs != "" {
+		k.Addresses = strings.Split(*addrs, ",")
+	}
+	if *expires > 0 {
+		t := time.Now().Add(*expires).UTC()
+		k.ExpiresAt = &t
+	}
+
+	token, err := k.CreateAPIKey()
+	if err != nil {
+		return err
+	}
+	sk, err := data.SigningKey(token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created key %s, the token and signing key are only shown once:\n%s\n%s\n", k.ID, token, sk)
+	return nil
+}
+
+func listKeys() error {
+	kl := data.NewAPIKeys()
+	if err := kl.GetAPIKeys(); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tADDRESSES\tEXPIRES\tCREATED")
+	for _, k := range *kl {
+		addrs := "all"
+		if len(k.Addresses) > 0 {
+			addrs = strings.Join(k.Addresses, ",")
+		}
+		exp := "never"
+		if k.ExpiresAt != nil {
+			exp = k.ExpiresAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, strings.Join(k.Scopes, ","), addrs, exp, k.CreatedAt.Format(time.RFC3339))
+	}
+
+	return tw.Flush()
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/edgestats/edgestats-server/data"
 	"github.com/edgestats/edgestats-server/handlers"
 	gh "github.com/gorilla/handlers"
-	"github.com/gorilla/mux"
 )
 
 var (
@@ -21,9 +20,25 @@ var (
 	srvLogDir     = "./logs"
 	srvAccessLogs = "./logs/access.log"
 	srvErrorLogs  = "./logs/error.log"
+	srvTLSCert    = "" // empty serves plain http
+	srvTLSKey     = ""
+	srvTLSCA      = "" // client cas of mutual tls
 )
 
 func main() {
+	// manage api keys or users instead of serving
+	cmds := map[string]func(args []string) error{"keys": runKeys, "users": runUsers}
+	if len(os.Args) > 1 && cmds[os.Args[1]] != nil {
+		run := cmds[os.Args[1]]
+		defer data.DB.Close()
+		if err := run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error with %s: %s\n", os.Args[1], err)
+			data.DB.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// set server log dir
 	if err := os.MkdirAll(srvLogDir, os.ModePerm); err != nil {
 		log.Fatalf("Error starting logs: %s\n", err)
@@ -48,57 +63,65 @@ func main() {
 	// close db session
 	defer data.DB.Close()
 
-	sm := mux.NewRouter()
-
-	// broadcasts endpoints
-	sm.HandleFunc("/stats/uptimes/broadcasts", h.CreateUMBroadcast).Methods(http.MethodPost)
-	sm.HandleFunc("/stats/uptimes/broadcasts", h.GetUMBroadcasts).Methods(http.MethodGet) // select * query
-	sm.HandleFunc("/stats/uptimes/broadcasts/{addr}/{min}", h.GetUMBroadcastsByAddrByRange).Methods(http.MethodGet)
-	sm.HandleFunc("/stats/uptimes/broadcasts/{addr}/{min}/{max}", h.GetUMBroadcastsByAddrByRange).Methods(http.MethodGet)
-
-	// peers endpoints
-	sm.HandleFunc("/stats/uptimes/peers", h.CreateNumPeers).Methods(http.MethodPost)
-	sm.HandleFunc("/stats/uptimes/peers", h.GetNumPeers).Methods(http.MethodGet)
-	sm.HandleFunc("/stats/uptimes/peers/{addr}/{min}", h.GetNumPeersByAddrByRange).Methods(http.MethodGet)
-	sm.HandleFunc("/stats/uptimes/peers/{addr}/{min}/{max}", h.GetNumPeersByAddrByRange).Methods(http.MethodGet)
-
-	// clusters endpoints
-	sm.HandleFunc("/clusters/uptimes/broadcasts/{addrs}", h.GetUMBroadcastsByCluster).Methods(http.MethodGet)
-	sm.HandleFunc("/clusters/uptimes/peers/{addrs}", h.GetNumPeersByCluster).Methods(http.MethodGet)
-
-	// blocks endpoints
-	sm.HandleFunc("/stats/blocks", h.CreateBlock).Methods(http.MethodPost)
-	sm.HandleFunc("/stats/blocks/{min}", h.GetBlocksByRange).Methods(http.MethodGet)
-	sm.HandleFunc("/stats/blocks/{min}/{max}", h.GetBlocksByRange).Methods(http.MethodGet)
-	sm.HandleFunc("/stats/blocks/misses/{addr}/{min}", h.GetMissedBlocksByAddrByRange).Methods(http.MethodGet)
-	sm.HandleFunc("/stats/blocks/misses/{addr}/{min}/{max}", h.GetMissedBlocksByAddrByRange).Methods(http.MethodGet)
+	sm := handlers.NewRouter(h)
 
 	s := &http.Server{
 		Addr:         fmt.Sprintf(":%v", srvPort),
-		Handler:      gh.LoggingHandler(accessLogFile, h.MiddlewareAuthz(gh.RecoveryHandler()(sm))),
+		Handler:      h.MiddlewareShareToken(gh.LoggingHandler(accessLogFile, h.MiddlewareRequestID(h.MiddlewareCORS(h.MiddlewareAuthz(gh.CompressHandler(gh.RecoveryHandler()(sm))))))),
 		ErrorLog:     l,
 		ReadTimeout:  6 * time.Second,
 		WriteTimeout: 6 * time.Second,
 		IdleTimeout:  30 * time.Second,
 	}
 
+	// set server tls if cert set
+	var tr *tlsReloader
+	if srvTLSCert != "" {
+		tr, err = newTLSReloader(srvTLSCert, srvTLSKey, srvTLSCA)
+		if err != nil {
+			log.Fatalf("Error starting tls: %s\n", err)
+		}
+		s.TLSConfig = tr.config()
+	}
+
 	go func() {
 		fmt.Printf("Initializing server on port: %s\n", srvPort)
 
-		err := s.ListenAndServe()
+		var err error
+		if tr != nil {
+			err = s.ListenAndServeTLS("", "")
+		} else {
+			err = s.ListenAndServe()
+		}
 		if err != nil && err != http.ErrServerClosed {
 			l.Fatalf("Error starting server: %s\n", err)
 		}
 	}()
 
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	sig := <-ch
+	for ; sig == syscall.SIGHUP; sig = <-ch {
+		// reload tls files for new connections
+		if tr == nil {
+			continue
+		}
+		if err := tr.reload(); err != nil {
+			l.Printf("Error reloading tls, keeping former cert: %s\n", err)
+			continue
+		}
+		fmt.Printf("Recieved %s signal, reloaded tls\n", sig)
+	}
 	fmt.Printf("Recieved %s signal, shutting down...\n", sig)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	s.Shutdown(ctx)
+
+	// write pending key uses
+	if err := h.Close(); err != nil {
+		l.Printf("Error recording key use: %s\n", err)
+	}
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// tlsReloader serves the cert, key & client cas of its files, replaced by
+// reload for new connections while open connections keep theirs
+type tlsReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string // client cas of mutual tls, empty disables client certs
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	cas  *x509.CertPool
+}
+
+func newTLSReloader(certFile, keyFile, caFile string) (*tlsReloader, error) {
+	tr := &tlsReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := tr.reload(); err != nil {
+		return nil, err
+	}
+
+	return tr, nil
+}
+
+// reload reads the files, the former cert is kept on error
+func (tr *tlsReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(tr.certFile, tr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	var cas *x509.CertPool
+	if tr.caFile != "" {
+		b, err := ioutil.ReadFile(tr.caFile)
+		if err != nil {
+			return err
+		}
+		cas = x509.NewCertPool()
+		if !cas.AppendCertsFromPEM(b) {
+			return fmt.Errorf("error no certificates in: %s", tr.caFile)
+		}
+	}
+
+	tr.mu.Lock()
+	tr.cert, tr.cas = &cert, cas
+	tr.mu.Unlock()
+
+	return nil
+}
+
+// config returns the server tls config, client certs are verified if given
+// so clients without one may still authenticate by api key
+func (tr *tlsReloader) config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			tr.mu.RLock()
+			defer tr.mu.RUnlock()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*tr.cert},
+				NextProtos:   []string{"h2", "http/1.1"},
+			}
+			if tr.cas != nil {
+				cfg.ClientCAs = tr.cas
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			return cfg, nil
+		},
+	}
+}
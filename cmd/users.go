@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+// runUsers manages the users of the db, the server must be stopped as the db
+// is locked while it runs
+func runUsers(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: users add|list|disable")
+	}
+
+	switch args[0] {
+	case "add":
+		return addUser(args[1:])
+	case "list":
+		return listUsers()
+	case "disable":
+		return disableUser(args[1:])
+	default:
+		return fmt.Errorf("unknown users command: %s", args[0])
+	}
+}
+
+func addUser(args []string) error {
+	fs := flag.NewFlagSet("users add", flag.ContinueOnError)
+	name := fs.String("name", "", "username of lowercase letters, digits, '.', '_' or '-'")
+	role := fs.String("role", data.RoleViewer, "role of the user: "+strings.Join(data.Roles, ", "))
+	addrs := fs.String("addresses", "", "comma separated address patterns the user may read & write, e.g. 0xabc*, empty allows all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// read password from first line of stdin, so it is not kept in the shell history
+	fmt.Fprint(os.Stderr, "Password: ")
+	pw, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && pw == "" {
+		return fmt.Errorf("error reading password: %s", err)
+	}
+
+	u := data.NewUser()
+	u.Username = *name
+	u.Role = *role
+	if *addrs != "" {
+		u.Addresses = strings.Split(*addrs, ",")
+	}
+	u.Password = strings.TrimRight(pw, "\r\n")
+	if err := u.CreateUser(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created user %s with role %s\n", u.Username, u.Role)
+	return nil
+}
+
+func listUsers() error {
+	ul := data.NewUsers()
+	if err := ul.GetUsers(); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USERNAME\tROLE\tADDRESSES\tDISABLED\tCREATED")
+	for _, u := range *ul {
+		addrs := "all"
+		if len(u.Addresses) > 0 {
+			addrs = strings.Join(u.Addresses, ",")
+		}
+		disabled := "no"
+		if u.DisabledAt != nil {
+			disabled = u.DisabledAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", u.Username, u.Role, addrs, disabled, u.CreatedAt.Format(time.RFC3339))
+	}
+
+	return tw.Flush()
+}
+
+func disableUser(args []string) error {
+	fs := flag.NewFlagSet("users disable", flag.ContinueOnError)
+	name := fs.String("name", "", "username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	u := data.NewUser()
+	if err := u.DisableUser(*name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Disabled user %s\n", u.Username)
+	return nil
+}
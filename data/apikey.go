@@ -0,0 +1,448 @@
+package data
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeIngest = "ingest"
+	ScopeRead   = "read"
+	ScopeAdmin  = "admin" // grants all scopes
+)
+
+var Scopes = []string{ScopeIngest, ScopeRead, ScopeAdmin}
+
+// APIKey is stored by id, the secret of its <id>.<secret> token is only kept
+// as sha256 hash
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	Addresses []string   `json:"addresses,omitempty"`    // address patterns, empty allows all
+	Routes    []string   `json:"routes,omitempty"`       // route names, empty allows all
+	Subject   string     `json:"cert_subject,omitempty"` // client certificate subject of mutual tls
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	PrevHash      string     `json:"prev_hash,omitempty"`       // hash of the rotated secret
+	PrevExpiresAt *time.Time `json:"prev_expires_at,omitempty"` // end of the rotated secret grace period
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP    string     `json:"last_used_ip,omitempty"`
+}
+
+// APIKeyToken is a key with its token & signing key, returned once on create
+// & rotate
+type APIKeyToken struct {
+	APIKey
+	Token      string `json:"token"`
+	SigningKey string `json:"signing_key"`
+}
+
+func (kt *APIKeyToken) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(kt)
+}
+
+func NewAPIKey() *APIKey {
+	return &APIKey{}
+}
+
+func (k *APIKey) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(k)
+}
+
+func (k *APIKey) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(k)
+}
+
+// CreateAPIKey stores a new key with the name, scopes, addresses, routes &
+// expiry of k and returns its token, which is not stored and cannot be read
+// again
+func (k *APIKey) CreateAPIKey() (string, error) {
+	// validate key
+	if strings.TrimSpace(k.Name) == "" {
+		return "", Errorf(ErrInvalidArgument, "error missing key name")
+	}
+	if len(k.Scopes) == 0 {
+		return "", Errorf(ErrInvalidArgument, "error missing key scopes")
+	}
+	for _, s := range k.Scopes {
+		if indexOf(Scopes, s) < 0 {
+			return "", Errorf(ErrInvalidArgument, "error with key scope: %s", s)
+		}
+	}
+	if err := checkAddrPatterns(k.Addresses); err != nil {
+		return "", err
+	}
+	for _, v := range k.Routes {
+		if strings.TrimSpace(v) == "" {
+			return "", Errorf(ErrInvalidArgument, "error with key route: %s", v)
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return "", Errorf(ErrInvalidArgument, "error key expiry in the past")
+	}
+
+	// generate id & secret
+	id, err := randomToken(8, hex.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	*k = APIKey{
+		ID:        id,
+		Name:      k.Name,
+		Hash:      hashSecret(secret),
+		Scopes:    k.Scopes,
+		Addresses: k.Addresses,
+		Routes:    k.Routes,
+		Subject:   k.Subject,
+		ExpiresAt: k.ExpiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	// set key & value
+	v, err := json.Marshal(k)
+	if err != nil {
+		return "", wrapError(ErrInternal, err)
+	}
+
+	// write data to db, the subject index points to the newest key of subject
+	recs := []*record{{bkt: []byte(authKeys), key: []byte(k.ID), val: v}}
+	if k.Subject != "" {
+		recs = append(recs, &record{bkt: []byte(authSubjects), key: []byte(k.Subject), val: []byte(k.ID)})
+	}
+	if err := writeRecords(recs); err != nil {
+		return "", err
+	}
+
+	return id + "." + secret, nil
+}
+
+func (k *APIKey) updateAPIKey() error {
+	// set key & value
+	v, err := json.Marshal(k)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// write data to db
+	return writeData([]byte(authKeys), []byte(k.ID), v)
+}
+
+// GetAPIKeyByToken reads the key of a token, unknown, mismatched & expired
+// tokens are all denied alike
+func (k *APIKey) GetAPIKeyByToken(token string) error {
+	denied := Errorf(ErrPermissionDenied, "error not authorized")
+
+	// split token
+	id, secret := token, ""
+	if i := strings.IndexByte(token, '.'); i > 0 {
+		id, secret = token[:i], token[i+1:]
+	}
+	hash := hashSecret(secret)
+
+	// read data from db
+	b, err := readData([]byte(authKeys), []byte(id))
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return denied
+	}
+
+	// unmarshal data to struct
+	stored := NewAPIKey()
+	if err := json.Unmarshal(b, stored); err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// compare hashes in constant time, the rotated secret until its grace ends
+	now := time.Now()
+	ok := subtle.ConstantTimeCompare([]byte(hash), []byte(stored.Hash)) == 1
+	prev := subtle.ConstantTimeCompare([]byte(hash), []byte(stored.PrevHash)) == 1
+	if !ok && !(prev && stored.PrevHash != "" && stored.PrevExpiresAt != nil && stored.PrevExpiresAt.After(now)) {
+		return denied
+	}
+	if stored.ExpiresAt != nil && !stored.ExpiresAt.After(now) {
+		return denied
+	}
+	if stored.RevokedAt != nil {
+		return denied
+	}
+
+	*k = *stored
+	k.redact()
+	return nil
+}
+
+// GetAPIKeyBySubject reads the newest key of a verified client certificate
+// subject, unknown, expired & revoked subjects are all denied alike
+func (k *APIKey) GetAPIKeyBySubject(subject string) error {
+	denied := Errorf(ErrPermissionDenied, "error not authorized")
+	if subject == "" {
+		return denied
+	}
+
+	// read data from db
+	id, err := readData([]byte(authSubjects), []byte(subject))
+	if err != nil {
+		return err
+	}
+	if id == nil {
+		return denied
+	}
+	b, err := readData([]byte(authKeys), id)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return denied
+	}
+
+	// unmarshal data to struct
+	stored := NewAPIKey()
+	if err := json.Unmarshal(b, stored); err != nil {
+		return wrapError(ErrInternal, err)
+	}
+	if stored.Subject != subject || stored.RevokedAt != nil || (stored.ExpiresAt != nil && !stored.ExpiresAt.After(time.Now())) {
+		return denied
+	}
+
+	*k = *stored
+	k.redact()
+	return nil
+}
+
+// GetAPIKey reads a key by id, without hashes
+func (k *APIKey) GetAPIKey(id string) error {
+	// read data from db
+	b, err := readData([]byte(authKeys), []byte(id))
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return Errorf(ErrNotFound, "error no key for id: %s", id)
+	}
+
+	// unmarshal data to struct
+	if err := json.Unmarshal(b, k); err != nil {
+		return wrapError(ErrInternal, err)
+	}
+	k.redact()
+
+	return nil
+}
+
+// RevokeAPIKey denies all further requests with the key of id and removes it
+// from the subject index
+func (k *APIKey) RevokeAPIKey(id string) error {
+	err := k.modifyAPIKey(id, func() error {
+		if k.RevokedAt == nil {
+			now := time.Now().UTC()
+			k.RevokedAt = &now
+		}
+		return nil
+	})
+	if err != nil || k.Subject == "" {
+		return err
+	}
+
+	return deleteData([]byte(authSubjects), []byte(k.Subject), []byte(id))
+}
+
+// RotateAPIKey replaces the secret of the key of id and returns its new
+// token, the former secret is still accepted during grace
+func (k *APIKey) RotateAPIKey(id string, grace time.Duration) (string, error) {
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	err = k.modifyAPIKey(id, func() error {
+		if k.RevokedAt != nil {
+			return Errorf(ErrInvalidArgument, "error key revoked: %s", id)
+		}
+
+		k.PrevHash, k.PrevExpiresAt = "", nil
+		if grace > 0 {
+			end := time.Now().Add(grace).UTC()
+			k.PrevHash, k.PrevExpiresAt = k.Hash, &end
+		}
+		k.Hash = hashSecret(secret)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id + "." + secret, nil
+}
+
+// KeyUse is the time & ip of the last request with a key
+type KeyUse struct {
+	Time time.Time
+	IP   string
+}
+
+// TouchAPIKeys records the last use of keys by id in one transaction, uses
+// of deleted keys are dropped
+func TouchAPIKeys(uses map[string]*KeyUse) error {
+	keys := make([][]byte, 0, len(uses))
+	for id := range uses {
+		keys = append(keys, []byte(id))
+	}
+
+	return updateDataKeys([]byte(authKeys), keys, func(key, v []byte) ([]byte, error) {
+		// unmarshal data to struct
+		k := NewAPIKey()
+		if err := json.Unmarshal(v, k); err != nil {
+			return nil, err
+		}
+
+		u := uses[string(key)]
+		if k.LastUsedAt != nil && !u.Time.After(*k.LastUsedAt) {
+			return v, nil
+		}
+		t := u.Time.UTC()
+		k.LastUsedAt, k.LastUsedIP = &t, u.IP
+
+		return json.Marshal(k)
+	})
+}
+
+// modifyAPIKey reads the key of id into k, applies fn & writes it back in one
+// transaction, k is left without hashes
+func (k *APIKey) modifyAPIKey(id string, fn func() error) error {
+	err := updateData([]byte(authKeys), []byte(id), func(v []byte) ([]byte, error) {
+		if v == nil {
+			return nil, Errorf(ErrNotFound, "error no key for id: %s", id)
+		}
+
+		// unmarshal data to struct
+		*k = APIKey{}
+		if err := json.Unmarshal(v, k); err != nil {
+			return nil, err
+		}
+
+		if err := fn(); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(k)
+	})
+	if err != nil {
+		return err
+	}
+
+	k.redact()
+	return nil
+}
+
+// redact removes the hashes of a key read from db
+func (k *APIKey) redact() {
+	k.Hash = ""
+	k.PrevHash = ""
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsAddr reports whether the key may read & write data of addr, addresses
+// match the patterns of the key case insensitive
+func (k *APIKey) AllowsAddr(addr string) bool {
+	if len(k.Addresses) == 0 {
+		return true
+	}
+
+	for _, a := range k.Addresses {
+		if ok, _ := path.Match(strings.ToLower(a), strings.ToLower(addr)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkAddrPatterns validates the address patterns of keys & users
+func checkAddrPatterns(addrs []string) error {
+	for _, a := range addrs {
+		if _, err := path.Match(a, ""); err != nil || a == "" {
+			return Errorf(ErrInvalidArgument, "error with address: %s", a)
+		}
+	}
+
+	return nil
+}
+
+// AllowsRoute reports whether the key may call the route of name
+func (k *APIKey) AllowsRoute(name string) bool {
+	return len(k.Routes) == 0 || indexOf(k.Routes, name) >= 0
+}
+
+type APIKeys []*APIKey
+
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{}
+}
+
+func (kl *APIKeys) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(kl)
+}
+
+func (kl *APIKeys) GetAPIKeys() error {
+	// read data from db
+	buf, err := scanData([]byte(authKeys), &Page{Order: OrderAsc})
+	if err != nil {
+		return err
+	}
+
+	// unmarshal data to struct, without hashes
+	for _, b := range buf {
+		k := NewAPIKey()
+		if err := json.Unmarshal(b, k); err != nil {
+			return wrapError(ErrInternal, err)
+		}
+		k.redact()
+
+		*kl = append(*kl, k)
+	}
+
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", wrapError(ErrInternal, err)
+	}
+
+	return encode(b), nil
+}
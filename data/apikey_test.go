@@ -0,0 +1,242 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	want := &APIKey{}
+	got := NewAPIKey()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewAPIKey() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestAPIKeyCreateAPIKey(t *testing.T) {
+	setTestDB(t)
+
+	// test invalid scope & route
+	for _, k := range []*APIKey{
+		{Name: "dashboard", Scopes: []string{"write"}},
+		{Name: "dashboard", Scopes: []string{ScopeRead}, Routes: []string{""}},
+	} {
+		if _, err := k.CreateAPIKey(); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("data.CreateAPIKey(%v) returned: %v, wanted: %v", k, err, ErrInvalidArgument)
+		}
+	}
+
+	// test created key by token
+	k := &APIKey{Name: "dashboard", Scopes: []string{ScopeRead}, Routes: []string{"GetHealths"}}
+	token, err := k.CreateAPIKey()
+	if err != nil {
+		t.Fatalf("data.CreateAPIKey() returned error: %v", err)
+	}
+
+	got := NewAPIKey()
+	if err := got.GetAPIKeyByToken(token); err != nil || got.ID != k.ID || got.Hash != "" || got.Name != "dashboard" || !got.AllowsRoute("GetHealths") || got.AllowsRoute("GetUptimes") {
+		t.Fatalf("data.GetAPIKeyByToken() returned: %v, %v, wanted: %v", got, err, k)
+	}
+}
+
+func TestAPIKeyGetAPIKeyByToken(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}}
+	token, err := k.CreateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test denied tokens
+	past := time.Now().Add(-time.Hour)
+	expired := &APIKey{ID: "0a1b2c3d", Name: "old", Hash: hashSecret("secret"), Scopes: []string{ScopeRead}, ExpiresAt: &past}
+	if err := expired.updateAPIKey(); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"", "unknown", k.ID, k.ID + ".wrong", token + "x", "0a1b2c3d.secret"} {
+		if err := NewAPIKey().GetAPIKeyByToken(v); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.GetAPIKeyByToken(%q) returned: %v, wanted: %v", v, err, ErrPermissionDenied)
+		}
+	}
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	k := &APIKey{Scopes: []string{ScopeIngest}}
+	if !k.HasScope(ScopeIngest) || k.HasScope(ScopeRead) {
+		t.Fatalf("data.HasScope() returned wrong scopes for: %v", k.Scopes)
+	}
+
+	// test admin grants all scopes
+	k = &APIKey{Scopes: []string{ScopeAdmin}}
+	if !k.HasScope(ScopeIngest) || !k.HasScope(ScopeRead) {
+		t.Fatalf("data.HasScope() returned wrong scopes for: %v", k.Scopes)
+	}
+}
+
+func TestAPIKeyAllowsAddr(t *testing.T) {
+	k := &APIKey{Addresses: []string{"0xabc*", "0xDEF123"}}
+	for addr, want := range map[string]bool{"0xabc": true, "0xABC999": true, "0xdef123": true, "0xdef1234": false, "0x123": false} {
+		if got := k.AllowsAddr(addr); got != want {
+			t.Fatalf("data.AllowsAddr(%q) returned: %v, wanted: %v", addr, got, want)
+		}
+	}
+
+	// test no patterns allow all
+	if !NewAPIKey().AllowsAddr("0x123") {
+		t.Fatalf("data.AllowsAddr() returned: %v, wanted: %v", false, true)
+	}
+
+	// test invalid pattern
+	setTestDB(t)
+	k = &APIKey{Name: "edge", Scopes: []string{ScopeIngest}, Addresses: []string{"0x[abc"}}
+	if _, err := k.CreateAPIKey(); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("data.CreateAPIKey() returned: %v, wanted: %v", err, ErrInvalidArgument)
+	}
+}
+
+func TestAPIKeysGetAPIKeys(t *testing.T) {
+	setTestDB(t)
+
+	for _, n := range []string{"edge", "dashboard"} {
+		if _, err := (&APIKey{Name: n, Scopes: []string{ScopeRead}}).CreateAPIKey(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kl := NewAPIKeys()
+	if err := kl.GetAPIKeys(); err != nil || len(*kl) != 2 {
+		t.Fatalf("data.GetAPIKeys() returned: %v, %v, wanted 2 keys", kl, err)
+	}
+	for _, k := range *kl {
+		if k.Hash != "" {
+			t.Fatalf("data.GetAPIKeys() returned hash for key: %v", k.ID)
+		}
+	}
+}
+
+func TestAPIKeyRevokeAPIKey(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}}
+	token, err := k.CreateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := NewAPIKey()
+	if err := got.RevokeAPIKey(k.ID); err != nil || got.RevokedAt == nil || got.Hash != "" {
+		t.Fatalf("data.RevokeAPIKey() returned: %v, %v", got, err)
+	}
+	if err := NewAPIKey().GetAPIKeyByToken(token); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.GetAPIKeyByToken() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+
+	// test unknown key
+	if err := NewAPIKey().RevokeAPIKey("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("data.RevokeAPIKey() returned: %v, wanted: %v", err, ErrNotFound)
+	}
+}
+
+func TestAPIKeyRotateAPIKey(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}}
+	old, err := k.CreateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test both secrets during grace
+	token, err := NewAPIKey().RotateAPIKey(k.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("data.RotateAPIKey() returned error: %v", err)
+	}
+	for _, v := range []string{old, token} {
+		if err := NewAPIKey().GetAPIKeyByToken(v); err != nil {
+			t.Fatalf("data.GetAPIKeyByToken() returned error: %v", err)
+		}
+	}
+
+	// test former secret denied without grace
+	if token, err = NewAPIKey().RotateAPIKey(k.ID, 0); err != nil {
+		t.Fatalf("data.RotateAPIKey() returned error: %v", err)
+	}
+	if err := NewAPIKey().GetAPIKeyByToken(old); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.GetAPIKeyByToken() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+	if err := NewAPIKey().GetAPIKeyByToken(token); err != nil {
+		t.Fatalf("data.GetAPIKeyByToken() returned error: %v", err)
+	}
+}
+
+func TestTouchAPIKeys(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}}
+	if _, err := k.CreateAPIKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	// test uses of stored & deleted keys
+	used := time.Now().UTC()
+	if err := TouchAPIKeys(map[string]*KeyUse{k.ID: {used, "10.0.0.1"}, "deleted": {used, "10.0.0.2"}}); err != nil {
+		t.Fatalf("data.TouchAPIKeys() returned error: %v", err)
+	}
+	got := NewAPIKey()
+	if err := got.GetAPIKey(k.ID); err != nil || got.LastUsedIP != "10.0.0.1" || !got.LastUsedAt.Equal(used) {
+		t.Fatalf("data.GetAPIKey() returned: %v, %v", got, err)
+	}
+	if err := NewAPIKey().GetAPIKey("deleted"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("data.GetAPIKey() returned: %v, wanted: %v", err, ErrNotFound)
+	}
+
+	// test older use kept
+	if err := TouchAPIKeys(map[string]*KeyUse{k.ID: {used.Add(-time.Hour), "10.0.0.3"}}); err != nil {
+		t.Fatal(err)
+	}
+	got = NewAPIKey()
+	if err := got.GetAPIKey(k.ID); err != nil || got.LastUsedIP != "10.0.0.1" {
+		t.Fatalf("data.GetAPIKey() returned: %v, %v", got, err)
+	}
+}
+
+func TestAPIKeyGetAPIKeyBySubject(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}, Subject: "CN=edge-01,O=Operator"}
+	if _, err := k.CreateAPIKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := NewAPIKey()
+	if err := got.GetAPIKeyBySubject("CN=edge-01,O=Operator"); err != nil || got.ID != k.ID || got.Hash != "" {
+		t.Fatalf("data.GetAPIKeyBySubject() returned: %v, %v, wanted: %v", got, err, k)
+	}
+
+	// test newest key of subject, revoking the former key keeps it
+	k2 := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}, Subject: "CN=edge-01,O=Operator"}
+	if _, err := k2.CreateAPIKey(); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAPIKey().RevokeAPIKey(k.ID); err != nil {
+		t.Fatal(err)
+	}
+	got = NewAPIKey()
+	if err := got.GetAPIKeyBySubject("CN=edge-01,O=Operator"); err != nil || got.ID != k2.ID {
+		t.Fatalf("data.GetAPIKeyBySubject() returned: %v, %v, wanted: %v", got, err, k2)
+	}
+
+	// test denied subjects
+	if err := NewAPIKey().RevokeAPIKey(k2.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"", "CN=edge-02,O=Operator", "CN=edge-01,O=Operator"} {
+		if err := NewAPIKey().GetAPIKeyBySubject(v); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.GetAPIKeyBySubject(%q) returned: %v, wanted: %v", v, err, ErrPermissionDenied)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+)
+
+// auditTimeFormat keeps audit keys of one length so they sort by time
+const auditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// AuditEntry records who called a write or admin route, entries are only
+// ever appended
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`            // api key id
+	Action    string    `json:"action"`           // route name
+	Target    string    `json:"target,omitempty"` // address or resource id
+	Outcome   string    `json:"outcome"`
+	Status    int       `json:"status"`
+	IP        string    `json:"ip,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+func NewAuditEntry() *AuditEntry {
+	return &AuditEntry{}
+}
+
+func (e *AuditEntry) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(e)
+}
+
+// CreateAuditEntry appends the entry at the current time
+func (e *AuditEntry) CreateAuditEntry() error {
+	id, err := randomToken(8, hex.EncodeToString)
+	if err != nil {
+		return err
+	}
+	e.ID = id
+	e.Time = time.Now().UTC()
+
+	// set key & value
+	k := []byte(e.Time.Format(auditTimeFormat) + "/" + e.ID)
+	v, err := json.Marshal(e)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// write data to db, existing keys are never replaced
+	if _, err := insertData([]byte(auditEntries), k, v, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AuditFilter selects audit entries, empty fields match all
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Min    string // range start as RFC3339 time or Unix seconds
+	Max    string // range end as RFC3339 time or Unix seconds
+}
+
+type AuditEntries []*AuditEntry
+
+func NewAuditEntries() *AuditEntries {
+	return &AuditEntries{}
+}
+
+func (al *AuditEntries) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(al)
+}
+
+// GetAuditEntries reads the entries of the filter, newest first by default
+func (al *AuditEntries) GetAuditEntries(f *AuditFilter, pg *Page) error {
+	// validate times
+	min, err := auditTime(f.Min)
+	if err != nil {
+		return Errorf(ErrInvalidArgument, "error with min time: %s", f.Min)
+	}
+	max, err := auditTime(f.Max)
+	if err != nil {
+		return Errorf(ErrInvalidArgument, "error with max time: %s", f.Max)
+	}
+
+	// filter by actor & action
+	if pg == nil {
+		pg = &Page{}
+	}
+	pg.matchValue = func(v []byte) bool {
+		e := NewAuditEntry()
+		if err := json.Unmarshal(v, e); err != nil {
+			return false
+		}
+		return (f.Actor == "" || e.Actor == f.Actor) && (f.Action == "" || e.Action == f.Action)
+	}
+
+	// read data from db
+	buf, err := scanDataByRange([]byte(auditEntries), min, max, pg)
+	if err != nil {
+		return err
+	}
+
+	// unmarshal data to struct
+	for _, b := range buf {
+		e := NewAuditEntry()
+		if err := json.Unmarshal(b, e); err != nil {
+			return wrapError(ErrInternal, err)
+		}
+
+		*al = append(*al, e)
+	}
+
+	return nil
+}
+
+// auditTime returns the audit key bound of a time, nil if empty
+func auditTime(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := parseTime(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(t.Format(auditTimeFormat)), nil
+}
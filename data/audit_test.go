@@ -0,0 +1,76 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewAuditEntry(t *testing.T) {
+	want := &AuditEntry{}
+	got := NewAuditEntry()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewAuditEntry() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestAuditEntriesGetAuditEntries(t *testing.T) {
+	setTestDB(t)
+
+	start := time.Now().UTC()
+	for _, e := range []*AuditEntry{
+		{Actor: "0a1b2c3d", Action: "CreateHeartbeat", Target: "0xabc", Outcome: OutcomeSuccess, Status: 201},
+		{Actor: "build", Action: "CreateAPIKey", Target: "0a1b2c3d", Outcome: OutcomeSuccess, Status: 201},
+		{Actor: "0a1b2c3d", Action: "CreateHeartbeat", Target: "0xdef", Outcome: OutcomeDenied, Status: 403},
+	} {
+		if err := e.CreateAuditEntry(); err != nil {
+			t.Fatalf("data.CreateAuditEntry() returned error: %v", err)
+		}
+	}
+
+	// test filters, newest first
+	for _, tc := range []struct {
+		f    *AuditFilter
+		want []string
+	}{
+		{&AuditFilter{}, []string{"0xdef", "0a1b2c3d", "0xabc"}},
+		{&AuditFilter{Actor: "0a1b2c3d"}, []string{"0xdef", "0xabc"}},
+		{&AuditFilter{Action: "CreateAPIKey"}, []string{"0a1b2c3d"}},
+		{&AuditFilter{Actor: "build", Action: "CreateHeartbeat"}, nil},
+		{&AuditFilter{Min: start.Add(time.Hour).Format(time.RFC3339)}, nil},
+		{&AuditFilter{Min: strconv.FormatInt(start.Unix(), 10), Max: start.Add(time.Hour).Format(time.RFC3339)}, []string{"0xdef", "0a1b2c3d", "0xabc"}},
+	} {
+		al := NewAuditEntries()
+		if err := al.GetAuditEntries(tc.f, nil); err != nil {
+			t.Fatalf("data.GetAuditEntries(%+v) returned error: %v", tc.f, err)
+		}
+
+		var got []string
+		for _, e := range *al {
+			got = append(got, e.Target)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("data.GetAuditEntries(%+v) returned: %v, wanted: %v", tc.f, got, tc.want)
+		}
+	}
+
+	// test filtered pages
+	pg := &Page{Limit: 1, Order: OrderAsc}
+	al := NewAuditEntries()
+	if err := al.GetAuditEntries(&AuditFilter{Actor: "0a1b2c3d"}, pg); err != nil || len(*al) != 1 || (*al)[0].Target != "0xabc" || pg.Next == "" {
+		t.Fatalf("data.GetAuditEntries() returned: %v, %v, next: %q", al, err, pg.Next)
+	}
+	pg = &Page{Limit: 1, Order: OrderAsc, Cursor: pg.Next}
+	al = NewAuditEntries()
+	if err := al.GetAuditEntries(&AuditFilter{Actor: "0a1b2c3d"}, pg); err != nil || len(*al) != 1 || (*al)[0].Target != "0xdef" || pg.Next != "" {
+		t.Fatalf("data.GetAuditEntries() returned: %v, %v, next: %q", al, err, pg.Next)
+	}
+
+	// test invalid times
+	if err := NewAuditEntries().GetAuditEntries(&AuditFilter{Min: "yesterday"}, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("data.GetAuditEntries() returned: %v, wanted: %v", err, ErrInvalidArgument)
+	}
+}
@@ -52,6 +52,19 @@ func (bk *Block) ToJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(bk)
 }
 
+// blockCSVHeader orders csv columns as json fields
+var blockCSVHeader = []string{"epoch", "height", "hash", "timestamp", "created_at"}
+
+func (bk *Block) csvRecord() []string {
+	return []string{
+		csvInt(bk.Epoch),
+		csvInt(bk.Height),
+		bk.Hash,
+		csvInt(bk.Timestamp),
+		csvTime(bk.CreatedAt),
+	}
+}
+
 func (bk *Block) unmarshalData(b []byte) error {
 	return json.Unmarshal(b, bk)
 }
@@ -65,12 +78,12 @@ func (bk *Block) CreateBlock() error {
 	// query explorer block
 	ek, err := queryExplorerBlock(bk.Height)
 	if err != nil {
-		return err
+		return wrapError(ErrUnavailable, err)
 	}
 
 	// map explorerBlock to Block
 	if err := bk.fromExplorerBlock(ek); err != nil {
-		return err
+		return wrapError(ErrUnavailable, err)
 	}
 
 	// write to blocks table
@@ -110,6 +123,10 @@ func queryExplorerBlock(h int) (*explorerBlock, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error explorer status: %s", resp.Status)
+	}
+
 	// unmarshal data explorerBlock
 	ek := newExplorerBlock()
 	if err := ek.FromJSON(resp.Body); err != nil {
@@ -144,7 +161,7 @@ func (bk *Block) createBlock() error {
 	k := bk.CreatedAt.Format(time.RFC3339)
 	v, err := json.Marshal(bk)
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write data to db
@@ -155,6 +172,33 @@ func (bk *Block) createBlock() error {
 	return nil
 }
 
+func (bk *Block) GetBlockByHeight(height int) error {
+	// read data from db, heights descend with block times
+	var found bool
+	err := visitData([]byte(statsBlocks), nil, func(v []byte) error {
+		vk := NewBlock()
+		if err := json.Unmarshal(v, vk); err != nil {
+			return err
+		}
+		if vk.Height > height {
+			return nil
+		}
+
+		if found = vk.Height == height; found {
+			*bk = *vk
+		}
+		return errStopScan
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return Errorf(ErrNotFound, "error no block for height: %d", height)
+	}
+
+	return nil
+}
+
 type Blocks []*Block
 
 func NewBlocks() *Blocks {
@@ -169,9 +213,32 @@ func (bk *Blocks) ToJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(bk)
 }
 
-func (bkl *Blocks) GetBlocks() error {
+func (bk *Blocks) ToCSV(w io.Writer, fields ...string) error {
+	l := *bk
+	return writeCSV(w, blockCSVHeader, fields, len(l), func(i int) []string { return l[i].csvRecord() })
+}
+
+func (bk *Blocks) csvHeader() []string {
+	return blockCSVHeader
+}
+
+func (bk *Blocks) csvDecode(b []byte) ([]string, error) {
+	v := NewBlock()
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, wrapError(ErrInternal, err)
+	}
+
+	return v.csvRecord(), nil
+}
+
+func (bk *Blocks) ToNDJSON(w io.Writer) error {
+	l := *bk
+	return writeNDJSON(w, len(l), func(i int) interface{} { return l[i] })
+}
+
+func (bkl *Blocks) GetBlocks(pg *Page) error {
 	// read data from db
-	buf, err := scanData([]byte(statsBlocks))
+	buf, err := scanData([]byte(statsBlocks), pg)
 	if err != nil {
 		return err
 	}
@@ -180,14 +247,15 @@ func (bkl *Blocks) GetBlocks() error {
 	return bkl.unmarshalData(buf)
 }
 
-func (bkl *Blocks) GetBlocksByRange(min, max string) error {
+func (bkl *Blocks) GetBlocksByRange(min, max string, pg *Page) error {
 	// validate times
-	if err := validateTimes(min, max); err != nil {
+	min, max, err := normalizeTimes(min, max)
+	if err != nil {
 		return err
 	}
 
 	// read data from db
-	buf, err := scanDataByRange([]byte(statsBlocks), []byte(min), []byte(max))
+	buf, err := scanDataByRange([]byte(statsBlocks), []byte(min), []byte(max), pg)
 	if err != nil {
 		return err
 	}
@@ -196,11 +264,27 @@ func (bkl *Blocks) GetBlocksByRange(min, max string) error {
 	return bkl.unmarshalData(buf)
 }
 
+func (bkl *Blocks) StreamBlocks(pg *Page, sw StreamWriter) error {
+	// stream data from db
+	return streamData([]byte(statsBlocks), nil, nil, nil, pg, sw)
+}
+
+func (bkl *Blocks) StreamBlocksByRange(min, max string, pg *Page, sw StreamWriter) error {
+	// validate times
+	min, max, err := normalizeTimes(min, max)
+	if err != nil {
+		return err
+	}
+
+	// stream data from db
+	return streamData([]byte(statsBlocks), nil, []byte(min), rangeMax(max), pg, sw)
+}
+
 func (bkl *Blocks) unmarshalData(buf [][]byte) error {
 	for _, b := range buf {
 		bk := NewBlock()
 		if err := json.Unmarshal(b, bk); err != nil {
-			return err
+			return wrapError(ErrInternal, err)
 		}
 
 		*bkl = append(*bkl, bk)
@@ -209,22 +293,32 @@ func (bkl *Blocks) unmarshalData(buf [][]byte) error {
 	return nil
 }
 
-func (bkl *Blocks) GetMissedBlocksByAddrByRange(addr, min, max string) error {
-	// get blocks by range // consider running in goroutine with channel
+func (bkl *Blocks) GetMissedBlocksByAddrByRange(addr, min, max string, pg *Page) error {
+	// get blocks by range after cursor // consider running in goroutine with channel
 	vkl := NewBlocks()
-	if err := vkl.GetBlocksByRange(min, max); err != nil {
+	var vpg *Page
+	if pg != nil {
+		vpg = &Page{Cursor: pg.Cursor, Order: pg.Order}
+	}
+	if err := vkl.GetBlocksByRange(min, max, vpg); err != nil {
 		return err
 	}
 
 	// get broadcasts by range // consider running in goroutine with channel
 	uml := NewUMBroadcasts()
-	if err := uml.GetUMBroadcastsByAddrByRange(addr, min, max); err != nil {
+	if err := uml.GetUMBroadcastsByAddrByRange(addr, min, max, nil); err != nil {
 		return err
 	}
 
 	// filter missed blocks
 	bkl.getMissedBlocks(vkl, uml)
 
+	// trim to page
+	if pg.full(len(*bkl) - 1) {
+		*bkl = (*bkl)[:pg.Limit]
+		pg.Next = encodeCursor([]byte((*bkl)[pg.Limit-1].CreatedAt.Format(time.RFC3339)))
+	}
+
 	return nil
 }
 
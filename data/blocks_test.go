@@ -2,6 +2,7 @@ package data
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"reflect"
 	"testing"
@@ -177,6 +178,30 @@ func TestBlockFromExplorerBlock(t *testing.T) {
 
 func TestBlockcreateBlock(t *testing.T) {}
 
+func TestBlockGetBlockByHeight(t *testing.T) {
+	setTestDB(t)
+
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:00:00Z")
+	for i, h := range []int{13040101, 13040201, 13040301} {
+		bk := &Block{Height: h, CreatedAt: vt.Add(time.Minute * time.Duration(i))}
+		if err := bk.createBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// test found block
+	bk := NewBlock()
+	if err := bk.GetBlockByHeight(13040201); err != nil || bk.Height != 13040201 {
+		t.Fatalf("data.GetBlockByHeight() returned: %v, %v, wanted: %v", bk.Height, err, 13040201)
+	}
+
+	// test missing block
+	bk = NewBlock()
+	if err := bk.GetBlockByHeight(13040200); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("data.GetBlockByHeight() returned: %v, wanted: %v", err, ErrNotFound)
+	}
+}
+
 func TestNewBlocks(t *testing.T) {
 	want := &Blocks{}
 	got := NewBlocks()
@@ -0,0 +1,163 @@
+package data
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVWriter writes records as csv rows, fields select and order the columns
+// by header name, no fields writes all columns
+type CSVWriter struct {
+	cw     *csv.Writer
+	header []string
+	cols   []int
+	row    []string
+	decode func(b []byte) ([]string, error) // csv record of a raw json record
+}
+
+// csvList is a list whose raw json records may be written as csv rows
+type csvList interface {
+	csvHeader() []string
+	csvDecode(b []byte) ([]string, error)
+}
+
+// NewCSVWriter returns the csv writer of the raw json records of a list type,
+// such as *Blocks
+func NewCSVWriter(w io.Writer, v interface{}, fields []string) (*CSVWriter, error) {
+	l, ok := v.(csvList)
+	if !ok {
+		return nil, Errorf(ErrInvalidArgument, "error csv not supported: %T", v)
+	}
+
+	c, err := newCSVWriter(w, l.csvHeader(), fields)
+	if err != nil {
+		return nil, err
+	}
+	c.decode = l.csvDecode
+
+	return c, nil
+}
+
+func newCSVWriter(w io.Writer, header, fields []string) (*CSVWriter, error) {
+	// select columns
+	cols := make([]int, 0, len(header))
+	if len(fields) == 0 {
+		for i := range header {
+			cols = append(cols, i)
+		}
+	}
+	for _, f := range fields {
+		i := indexOf(header, f)
+		if i < 0 {
+			return nil, Errorf(ErrInvalidArgument, "error with csv field: %s", f)
+		}
+		cols = append(cols, i)
+	}
+
+	return &CSVWriter{cw: csv.NewWriter(w), header: header, cols: cols, row: make([]string, len(cols))}, nil
+}
+
+// WriteHeader writes the names of the selected columns
+func (c *CSVWriter) WriteHeader() error {
+	for j, i := range c.cols {
+		c.row[j] = c.header[i]
+	}
+
+	return c.cw.Write(c.row)
+}
+
+// Record writes the row of a raw json record of a scan
+func (c *CSVWriter) Record(b []byte) error {
+	rec, err := c.decode(b)
+	if err != nil {
+		return err
+	}
+
+	return c.write(rec)
+}
+
+func (c *CSVWriter) write(rec []string) error {
+	for j, i := range c.cols {
+		c.row[j] = rec[i]
+	}
+
+	return c.cw.Write(c.row)
+}
+
+// Flush writes buffered rows
+func (c *CSVWriter) Flush() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// writeCSV writes the header and n records row by row
+func writeCSV(w io.Writer, header, fields []string, n int, record func(i int) []string) error {
+	c, err := newCSVWriter(w, header, fields)
+	if err != nil {
+		return err
+	}
+
+	// write header
+	if err := c.WriteHeader(); err != nil {
+		return err
+	}
+
+	// write records
+	for i := 0; i < n; i++ {
+		if err := c.write(record(i)); err != nil {
+			return err
+		}
+	}
+
+	return c.Flush()
+}
+
+// writeNDJSON writes n records as one json object per line
+func writeNDJSON(w io.Writer, n int, record func(i int) interface{}) error {
+	e := json.NewEncoder(w)
+	for i := 0; i < n; i++ {
+		if err := e.Encode(record(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexOf returns the index of s in l, -1 if l lacks s
+func indexOf(l []string, s string) int {
+	for i, v := range l {
+		if v == s {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func csvInt(v int) string {
+	return strconv.Itoa(v)
+}
+
+func csvBool(v bool) string {
+	return strconv.FormatBool(v)
+}
+
+func csvFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func csvTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func csvTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return csvTime(*t)
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWriteCSV(t *testing.T) {
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:00:00Z")
+	p2pl := &P2Ps{
+		{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16, CreatedAt: vt},
+		{Addr: "0x4d5e6f", NumPeers: 4, SufficientPeers: 16, CreatedAt: vt},
+	}
+
+	// test all columns
+	want := "address,num_peers,sufficient_peers,created_at\n" +
+		"0x1a2b3c,16,16,2021-11-28T22:00:00Z\n" +
+		"0x4d5e6f,4,16,2021-11-28T22:00:00Z\n"
+	buf := &bytes.Buffer{}
+	if err := p2pl.ToCSV(buf); err != nil || buf.String() != want {
+		t.Fatalf("data.WriteCSV() returned: %q, %v, wanted: %q", buf.String(), err, want)
+	}
+
+	// test selected columns in requested order
+	want = "num_peers,address\n16,0x1a2b3c\n4,0x4d5e6f\n"
+	buf.Reset()
+	if err := p2pl.ToCSV(buf, "num_peers", "address"); err != nil || buf.String() != want {
+		t.Fatalf("data.WriteCSV() returned: %q, %v, wanted: %q", buf.String(), err, want)
+	}
+
+	// test unknown column
+	if err := p2pl.ToCSV(&bytes.Buffer{}, "height"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("data.WriteCSV() returned: %v, wanted: %v", err, ErrInvalidArgument)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	// test rows of raw json records
+	want := "num_peers,address\n16,0x1a2b3c\n"
+	buf := &bytes.Buffer{}
+	c, err := NewCSVWriter(buf, &P2Ps{}, []string{"num_peers", "address"})
+	if err != nil {
+		t.Fatalf("data.NewCSVWriter() returned error: %v", err)
+	}
+	if err := c.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Record([]byte(`{"address":"0x1a2b3c","num_peers":16,"sufficient_peers":16,"created_at":"2021-11-28T22:00:00Z"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Flush(); err != nil || buf.String() != want {
+		t.Fatalf("data.CSVWriter() returned: %q, %v, wanted: %q", buf.String(), err, want)
+	}
+
+	// test unknown column & list type
+	if _, err := NewCSVWriter(buf, &Blocks{}, []string{"address"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("data.NewCSVWriter() returned: %v, wanted: %v", err, ErrInvalidArgument)
+	}
+	if _, err := NewCSVWriter(buf, &Healths{}, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("data.NewCSVWriter() returned: %v, wanted: %v", err, ErrInvalidArgument)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:00:00Z")
+	vkl := &Blocks{
+		{Epoch: 1, Height: 13040401, Hash: "0xabc", Timestamp: 1638136800, CreatedAt: vt},
+		{Epoch: 1, Height: 13040301, Hash: "0xdef", Timestamp: 1638136200, CreatedAt: vt},
+	}
+
+	want := `{"epoch":1,"height":13040401,"hash":"0xabc","timestamp":1638136800,"created_at":"2021-11-28T22:00:00Z"}` + "\n" +
+		`{"epoch":1,"height":13040301,"hash":"0xdef","timestamp":1638136200,"created_at":"2021-11-28T22:00:00Z"}` + "\n"
+	buf := &bytes.Buffer{}
+	if err := vkl.ToNDJSON(buf); err != nil || buf.String() != want {
+		t.Fatalf("data.WriteNDJSON() returned: %q, %v, wanted: %q", buf.String(), err, want)
+	}
+}
+
+func TestCSVHeaders(t *testing.T) {
+	// csv columns follow json fields
+	for _, v := range []struct {
+		got  []string
+		want int
+	}{
+		{umBroadcastCSVHeader, len(NewUMBroadcast().csvRecord())},
+		{p2pCSVHeader, len(NewP2P().csvRecord())},
+		{blockCSVHeader, len(NewBlock().csvRecord())},
+		{healthCSVHeader, len(NewHealth().csvRecord())},
+		{peerIncidentCSVHeader, len(NewPeerIncident().csvRecord())},
+		{timelineEventCSVHeader, len(NewTimelineEvent().csvRecord())},
+	} {
+		if len(v.got) != v.want {
+			t.Fatalf("data.CSVHeaders() returned: %v, wanted: %d columns", v.got, v.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrUnavailable      = errors.New("upstream unavailable")
+	ErrInternal         = errors.New("internal error")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Error is a data error of a kind, errors.Is matches both the kind and the wrapped error
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func Errorf(kind error, format string, a ...interface{}) error {
+	return &Error{kind, fmt.Errorf(format, a...)}
+}
+
+func wrapError(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// keep kind of already typed errors
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &Error{kind, err}
+}
@@ -0,0 +1,40 @@
+package data
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestErrorf(t *testing.T) {
+	err := Errorf(ErrNotFound, "error no data for address: %s", "0x1a2b3c")
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("data.Errorf() returned: %v, wanted kind: %v", err, ErrNotFound)
+	}
+	if errors.Is(err, ErrInternal) {
+		t.Fatalf("data.Errorf() returned: %v, wanted not kind: %v", err, ErrInternal)
+	}
+	if got := err.Error(); got != "error no data for address: 0x1a2b3c" {
+		t.Fatalf("data.Errorf() returned message: %v", got)
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	// test nil error
+	if err := wrapError(ErrInternal, nil); err != nil {
+		t.Fatalf("data.wrapError() returned: %v, wanted: %v", err, nil)
+	}
+
+	// test wrapped error
+	err := wrapError(ErrInternal, io.ErrUnexpectedEOF)
+	if !errors.Is(err, ErrInternal) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("data.wrapError() returned: %v, wanted kinds: %v, %v", err, ErrInternal, io.ErrUnexpectedEOF)
+	}
+
+	// test typed error keeps kind
+	err = wrapError(ErrInternal, Errorf(ErrNotFound, "error not found"))
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrInternal) {
+		t.Fatalf("data.wrapError() returned: %v, wanted kind: %v", err, ErrNotFound)
+	}
+}
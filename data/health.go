@@ -0,0 +1,275 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	HealthOnline   = "online"
+	HealthDegraded = "degraded"
+	HealthOffline  = "offline"
+)
+
+type HealthThresholds struct {
+	DegradedHeights int // heights behind latest block before node is degraded
+	OfflineHeights  int // heights behind latest block before node is offline
+}
+
+func NewHealthThresholds() *HealthThresholds {
+	return &HealthThresholds{
+		DegradedHeights: 200, // two missed broadcasts
+		OfflineHeights:  1000,
+	}
+}
+
+type Health struct {
+	Addr            string     `json:"address"`
+	Status          string     `json:"status"`
+	Reason          string     `json:"reason"`
+	Height          int        `json:"height"`
+	LatestHeight    int        `json:"latest_height"`
+	HeightsBehind   int        `json:"heights_behind"`
+	NumPeers        int        `json:"num_peers"`
+	SufficientPeers int        `json:"sufficient_peers"`
+	LastBroadcast   *time.Time `json:"last_broadcast"`
+}
+
+func NewHealth() *Health {
+	return &Health{}
+}
+
+func (hs *Health) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(hs)
+}
+
+func (hs *Health) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(hs)
+}
+
+// healthCSVHeader orders csv columns as json fields
+var healthCSVHeader = []string{"address", "status", "reason", "height", "latest_height", "heights_behind", "num_peers", "sufficient_peers", "last_broadcast"}
+
+func (hs *Health) csvRecord() []string {
+	return []string{
+		hs.Addr,
+		hs.Status,
+		hs.Reason,
+		csvInt(hs.Height),
+		csvInt(hs.LatestHeight),
+		csvInt(hs.HeightsBehind),
+		csvInt(hs.NumPeers),
+		csvInt(hs.SufficientPeers),
+		csvTimePtr(hs.LastBroadcast),
+	}
+}
+
+func (hs *Health) GetHealthByAddr(addr string, th *HealthThresholds) error {
+	// read latest broadcast from db
+	bu, err := readData([]byte(statsUptimesBroadcasts), []byte(addr))
+	if err != nil {
+		return err
+	}
+
+	// read latest peers from db
+	bp, err := readData([]byte(statsUptimesPeers), []byte(addr))
+	if err != nil {
+		return err
+	}
+
+	if bu == nil && bp == nil {
+		return Errorf(ErrNotFound, "error no data for address: %s", addr)
+	}
+
+	// unmarshal data to structs
+	um, p2p, err := unmarshalHealthData(bu, bp)
+	if err != nil {
+		return err
+	}
+
+	// evaluate status
+	hs.Addr = addr
+	hs.evaluate(um, p2p, queryLatestHeight(um), th)
+
+	return nil
+}
+
+func (hs *Health) evaluate(um *UMBroadcast, p2p *P2P, latest int, th *HealthThresholds) {
+	hs.LatestHeight = latest
+
+	// prefer peers record, fallback to broadcast peers
+	if um != nil {
+		hs.Height = um.Height
+		hs.NumPeers = um.NumPeers
+		hs.SufficientPeers = um.SufficientPeers
+
+		t := um.CreatedAt
+		hs.LastBroadcast = &t
+	}
+	if p2p != nil {
+		hs.NumPeers = int(p2p.NumPeers)
+		hs.SufficientPeers = int(p2p.SufficientPeers)
+	}
+
+	// decide status
+	if um == nil {
+		hs.Status = HealthOffline
+		hs.Reason = "no broadcast received"
+		return
+	}
+
+	if hs.HeightsBehind = latest - hs.Height; hs.HeightsBehind < 0 {
+		hs.HeightsBehind = 0
+	}
+
+	switch {
+	case hs.HeightsBehind >= th.OfflineHeights:
+		hs.Status = HealthOffline
+		hs.Reason = fmt.Sprintf("last broadcast %d heights behind latest block", hs.HeightsBehind)
+	case hs.HeightsBehind >= th.DegradedHeights:
+		hs.Status = HealthDegraded
+		hs.Reason = fmt.Sprintf("last broadcast %d heights behind latest block", hs.HeightsBehind)
+	case hs.NumPeers < hs.SufficientPeers:
+		hs.Status = HealthDegraded
+		hs.Reason = fmt.Sprintf("insufficient peers: %d of %d", hs.NumPeers, hs.SufficientPeers)
+	default:
+		hs.Status = HealthOnline
+		hs.Reason = "broadcasting with sufficient peers"
+	}
+}
+
+func queryLatestHeight(uml ...*UMBroadcast) int {
+	var h int
+
+	// read last block from db
+	b, err := readLastData([]byte(statsBlocks))
+	if err == nil && b != nil {
+		bk := NewBlock()
+		if err := bk.unmarshalData(b); err == nil {
+			h = bk.Height
+		}
+	}
+
+	// broadcasts may be ahead of blocks table
+	for _, v := range uml {
+		if v != nil && v.Height > h {
+			h = v.Height
+		}
+	}
+
+	return h
+}
+
+func unmarshalHealthData(bu, bp []byte) (*UMBroadcast, *P2P, error) {
+	var um *UMBroadcast
+	var p2p *P2P
+
+	if bu != nil {
+		um = NewUMBroadcast()
+		if err := json.Unmarshal(bu, um); err != nil {
+			return nil, nil, wrapError(ErrInternal, err)
+		}
+	}
+
+	if bp != nil {
+		p2p = NewP2P()
+		if err := json.Unmarshal(bp, p2p); err != nil {
+			return nil, nil, wrapError(ErrInternal, err)
+		}
+	}
+
+	return um, p2p, nil
+}
+
+type Healths []*Health
+
+func NewHealths() *Healths {
+	return &Healths{}
+}
+
+func (hl *Healths) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(hl)
+}
+
+func (hl *Healths) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(hl)
+}
+
+func (hl *Healths) ToCSV(w io.Writer, fields ...string) error {
+	l := *hl
+	return writeCSV(w, healthCSVHeader, fields, len(l), func(i int) []string { return l[i].csvRecord() })
+}
+
+func (hl *Healths) ToNDJSON(w io.Writer) error {
+	l := *hl
+	return writeNDJSON(w, len(l), func(i int) interface{} { return l[i] })
+}
+
+func (hl *Healths) GetHealths(th *HealthThresholds, pg *Page) error {
+	// read latest broadcasts from db
+	uml := NewUMBroadcasts()
+	if err := uml.GetUMBroadcasts(pg.filter()); err != nil {
+		return err
+	}
+
+	// read latest peers from db
+	p2pl := NewP2Ps()
+	if err := p2pl.GetNumPeers(pg.filter()); err != nil {
+		return err
+	}
+
+	// evaluate status
+	hl.evaluate(uml, p2pl, queryLatestHeight(*uml...), th)
+	if !pg.ascending(true) {
+		hl.reverse()
+	}
+
+	// trim to page
+	lo, hi := pg.window(len(*hl))
+	*hl = (*hl)[lo:hi]
+
+	return nil
+}
+
+func (hl *Healths) evaluate(uml *UMBroadcasts, p2pl *P2Ps, latest int, th *HealthThresholds) {
+	// create maps of known nodes
+	mu := make(map[string]*UMBroadcast)
+	for _, v := range *uml {
+		mu[v.Addr] = v
+	}
+	mp := make(map[string]*P2P)
+	for _, v := range *p2pl {
+		mp[v.Addr] = v
+	}
+
+	var addrl []string
+	for k := range mu {
+		addrl = append(addrl, k)
+	}
+	for k := range mp {
+		if mu[k] == nil {
+			addrl = append(addrl, k)
+		}
+	}
+
+	// sort alphanumeric
+	sort.Strings(addrl)
+
+	for _, addr := range addrl {
+		hs := NewHealth()
+		hs.Addr = addr
+		hs.evaluate(mu[addr], mp[addr], latest, th)
+
+		*hl = append(*hl, hs)
+	}
+}
+
+func (hl *Healths) reverse() {
+	l := *hl
+	for i, j := 0, len(l)-1; i < j; i, j = i+1, j-1 {
+		l[i], l[j] = l[j], l[i]
+	}
+}
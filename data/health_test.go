@@ -0,0 +1,92 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewHealthThresholds(t *testing.T) {
+	want := &HealthThresholds{DegradedHeights: 200, OfflineHeights: 1000}
+	got := NewHealthThresholds()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewHealthThresholds() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestNewHealth(t *testing.T) {
+	want := &Health{}
+	got := NewHealth()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewHealth() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestHealthGetHealthByAddr(t *testing.T) {}
+
+func TestHealthEvaluate(t *testing.T) {
+	th := NewHealthThresholds()
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:49:51Z")
+	um := &UMBroadcast{Addr: "0x1a2b3c", Height: 13040101, NumPeers: 16, SufficientPeers: 16, CreatedAt: vt}
+
+	tests := []struct {
+		name   string
+		um     *UMBroadcast
+		p2p    *P2P
+		latest int
+		want   string
+	}{
+		{"online", um, nil, 13040101, HealthOnline},
+		{"degraded behind", um, nil, 13040301, HealthDegraded},
+		{"offline behind", um, nil, 13041101, HealthOffline},
+		{"degraded peers", um, &P2P{Addr: "0x1a2b3c", NumPeers: 4, SufficientPeers: 16}, 13040101, HealthDegraded},
+		{"offline no broadcast", nil, &P2P{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16}, 13040101, HealthOffline},
+	}
+
+	for _, tt := range tests {
+		got := NewHealth()
+		got.evaluate(tt.um, tt.p2p, tt.latest, th)
+
+		if got.Status != tt.want {
+			t.Fatalf("data.HealthEvaluate() %s returned: %v (%s), wanted: %v", tt.name, got.Status, got.Reason, tt.want)
+		}
+	}
+}
+
+func TestNewHealths(t *testing.T) {
+	want := &Healths{}
+	got := NewHealths()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewHealths() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestHealthsGetHealths(t *testing.T) {}
+
+func TestHealthsEvaluate(t *testing.T) {
+	th := NewHealthThresholds()
+	uml := &UMBroadcasts{
+		{Addr: "0xdef678", Height: 13040101, NumPeers: 16, SufficientPeers: 16},
+		{Addr: "0xabc123", Height: 13040101, NumPeers: 16, SufficientPeers: 16},
+	}
+	p2pl := &P2Ps{
+		{Addr: "0xabc123", NumPeers: 2, SufficientPeers: 16},
+		{Addr: "0xbcd456", NumPeers: 16, SufficientPeers: 16},
+	}
+
+	got := NewHealths()
+	got.evaluate(uml, p2pl, 13040101, th)
+
+	want := []string{"0xabc123", HealthDegraded, "0xbcd456", HealthOffline, "0xdef678", HealthOnline}
+	if len(*got) != 3 {
+		t.Fatalf("data.HealthsEvaluate() returned %d nodes, wanted: %d", len(*got), 3)
+	}
+	for i, v := range *got {
+		if v.Addr != want[i*2] || v.Status != want[i*2+1] {
+			t.Fatalf("data.HealthsEvaluate() returned: %s %s, wanted: %s %s", v.Addr, v.Status, want[i*2], want[i*2+1])
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+)
+
+type Heartbeat struct {
+	UMBroadcast
+}
+
+func NewHeartbeat() *Heartbeat {
+	return &Heartbeat{}
+}
+
+func (hb *Heartbeat) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(hb)
+}
+
+func (hb *Heartbeat) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(hb)
+}
+
+func (hb *Heartbeat) CreateHeartbeat() error {
+	// validate address
+	if hb.Addr == "" {
+		return Errorf(ErrInvalidArgument, "error missing address")
+	}
+
+	// set created time if not sent
+	if hb.CreatedAt.IsZero() {
+		hb.CreatedAt = time.Now().UTC()
+	}
+
+	um := &hb.UMBroadcast
+	p2p := hb.toP2P()
+
+	// marshal broadcast & peers
+	vu, err := json.Marshal(um)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+	vp, err := json.Marshal(p2p)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// write to stats current & history tables in one transaction
+	k := []byte(hb.CreatedAt.Format(time.RFC3339))
+	addr := []byte(hb.Addr)
+	recs := []*record{
+		{bkt: []byte(statsUptimesBroadcasts), key: addr, val: vu},
+		{bkt: []byte(statsUptimesBroadcatsByAddr), nst: addr, key: k, val: vu},
+		{bkt: []byte(statsUptimesPeers), key: addr, val: vp},
+		{bkt: []byte(statsUptimesPeersByAddr), nst: addr, key: k, val: vp},
+	}
+	if err := writeRecords(recs); err != nil {
+		return err
+	}
+
+	// write block to blocks table
+	bk := NewBlock()
+	bk.Height = um.Height
+	if err := bk.CreateBlock(); err != nil {
+		// return err // may return 400 error if block not yet in explorer
+	}
+
+	return nil
+}
+
+func (hb *Heartbeat) toP2P() *P2P {
+	p2p := NewP2P()
+	p2p.Addr = hb.Addr
+	p2p.NumPeers = clampInt8(hb.NumPeers)
+	p2p.SufficientPeers = clampInt8(hb.SufficientPeers)
+	p2p.CreatedAt = hb.CreatedAt
+
+	return p2p
+}
+
+func clampInt8(v int) int8 {
+	if v > math.MaxInt8 {
+		return math.MaxInt8
+	}
+	if v < math.MinInt8 {
+		return math.MinInt8
+	}
+
+	return int8(v)
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewHeartbeat(t *testing.T) {
+	want := &Heartbeat{}
+	got := NewHeartbeat()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewHeartbeat() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestHeartbeatFromJSON(t *testing.T) {
+	b := []byte(`{"block":"0x35d7858a13c4d76c9caac19e30ca7a7f13c26a0c494bb945561a343a9613acc7","height":13040101,"address":"0x5b8c84db6f40bf45","signature":"E1A035D7858A13C4D","timestamp":1638139521,"num_peers":16,"sufficient_peers":16,"created_at":"2021-11-28T22:49:51.387Z"}`)
+	buf := bytes.NewReader(b)
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:49:51.387Z")
+
+	want := &Heartbeat{
+		UMBroadcast{
+			Block:           "0x35d7858a13c4d76c9caac19e30ca7a7f13c26a0c494bb945561a343a9613acc7",
+			Height:          13040101,
+			Addr:            "0x5b8c84db6f40bf45",
+			Signature:       "E1A035D7858A13C4D",
+			Timestamp:       1638139521,
+			NumPeers:        16,
+			SufficientPeers: 16,
+			CreatedAt:       vt,
+		},
+	}
+	got := NewHeartbeat()
+	if err := got.FromJSON(buf); err != nil {
+		t.Fatalf("data.HeartbeatFromJSON() returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.HeartbeatFromJSON() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestHeartbeatCreateHeartbeat(t *testing.T) {}
+
+func TestHeartbeatToP2P(t *testing.T) {
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:49:51.387Z")
+	hb := &Heartbeat{
+		UMBroadcast{
+			Height:          13040101,
+			Addr:            "0x5b8c84db6f40bf45",
+			NumPeers:        300,
+			SufficientPeers: 16,
+			CreatedAt:       vt,
+		},
+	}
+
+	want := &P2P{
+		Addr:            "0x5b8c84db6f40bf45",
+		NumPeers:        127,
+		SufficientPeers: 16,
+		CreatedAt:       vt,
+	}
+	got := hb.toP2P()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.HeartbeatToP2P() returned: %v, wanted: %v", got, want)
+	}
+}
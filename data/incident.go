@@ -0,0 +1,143 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type PeerIncident struct {
+	Addr            string    `json:"address"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Duration        int       `json:"duration"` // seconds
+	MinPeers        int       `json:"min_peers"`
+	SufficientPeers int       `json:"sufficient_peers"`
+	Samples         int       `json:"samples"`
+	Ongoing         bool      `json:"ongoing"`
+}
+
+func NewPeerIncident() *PeerIncident {
+	return &PeerIncident{}
+}
+
+func (pi *PeerIncident) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(pi)
+}
+
+func (pi *PeerIncident) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(pi)
+}
+
+// peerIncidentCSVHeader orders csv columns as json fields
+var peerIncidentCSVHeader = []string{"address", "start", "end", "duration", "min_peers", "sufficient_peers", "samples", "ongoing"}
+
+func (pi *PeerIncident) csvRecord() []string {
+	return []string{
+		pi.Addr,
+		csvTime(pi.Start),
+		csvTime(pi.End),
+		csvInt(pi.Duration),
+		csvInt(pi.MinPeers),
+		csvInt(pi.SufficientPeers),
+		csvInt(pi.Samples),
+		csvBool(pi.Ongoing),
+	}
+}
+
+type PeerIncidents []*PeerIncident
+
+func NewPeerIncidents() *PeerIncidents {
+	return &PeerIncidents{}
+}
+
+func (pil *PeerIncidents) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(pil)
+}
+
+func (pil *PeerIncidents) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(pil)
+}
+
+func (pil *PeerIncidents) ToCSV(w io.Writer, fields ...string) error {
+	l := *pil
+	return writeCSV(w, peerIncidentCSVHeader, fields, len(l), func(i int) []string { return l[i].csvRecord() })
+}
+
+func (pil *PeerIncidents) ToNDJSON(w io.Writer) error {
+	l := *pil
+	return writeNDJSON(w, len(l), func(i int) interface{} { return l[i] })
+}
+
+func (pil *PeerIncidents) GetPeerIncidentsByAddrByRange(addr, min, max string, pg *Page) error {
+	// get peers by range
+	p2pl := NewP2Ps()
+	if err := p2pl.GetNumPeersByAddrByRange(addr, min, max, nil); err != nil {
+		return err
+	}
+
+	// filter insufficient peers intervals
+	pil.findPeerIncidents(p2pl)
+	if pg.ascending(false) {
+		pil.reverse()
+	}
+
+	// trim to page
+	lo, hi := pg.window(len(*pil))
+	*pil = (*pil)[lo:hi]
+
+	return nil
+}
+
+func (pil *PeerIncidents) findPeerIncidents(p2pl *P2Ps) {
+	var l []*PeerIncident
+	var pi *PeerIncident
+
+	// scan in ascending order, peers are in descending order
+	for i := len(*p2pl) - 1; i >= 0; i-- {
+		v := (*p2pl)[i]
+
+		// close incident on first sufficient sample
+		if v.NumPeers >= v.SufficientPeers {
+			if pi != nil {
+				pi.End = v.CreatedAt
+				pi.Duration = int(pi.End.Sub(pi.Start).Seconds())
+				pi.Ongoing = false
+				pi = nil
+			}
+			continue
+		}
+
+		// open incident on first insufficient sample
+		if pi == nil {
+			pi = NewPeerIncident()
+			pi.Addr = v.Addr
+			pi.Start = v.CreatedAt
+			pi.MinPeers = int(v.NumPeers)
+			l = append(l, pi)
+		}
+
+		pi.End = v.CreatedAt
+		pi.Duration = int(pi.End.Sub(pi.Start).Seconds())
+		pi.Ongoing = true
+		pi.Samples++
+		if int(v.NumPeers) < pi.MinPeers {
+			pi.MinPeers = int(v.NumPeers)
+		}
+		if int(v.SufficientPeers) > pi.SufficientPeers {
+			pi.SufficientPeers = int(v.SufficientPeers)
+		}
+	}
+
+	// return in descending order
+	for i := len(l) - 1; i >= 0; i-- {
+		*pil = append(*pil, l[i])
+	}
+}
+
+func (pil *PeerIncidents) reverse() {
+	l := *pil
+	for i, j := 0, len(l)-1; i < j; i, j = i+1, j-1 {
+		l[i], l[j] = l[j], l[i]
+	}
+}
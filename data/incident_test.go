@@ -0,0 +1,79 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewPeerIncident(t *testing.T) {
+	want := &PeerIncident{}
+	got := NewPeerIncident()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewPeerIncident() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestNewPeerIncidents(t *testing.T) {
+	want := &PeerIncidents{}
+	got := NewPeerIncidents()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewPeerIncidents() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestPeerIncidentsGetPeerIncidentsByAddrByRange(t *testing.T) {}
+
+func TestPeerIncidentsFindPeerIncidents(t *testing.T) {
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:00:00Z")
+	at := func(m int) time.Time { return vt.Add(time.Minute * time.Duration(m)) }
+
+	// peers in descending order
+	p2pl := &P2Ps{
+		{Addr: "0x1a2b3c", NumPeers: 3, SufficientPeers: 16, CreatedAt: at(60)},
+		{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16, CreatedAt: at(50)},
+		{Addr: "0x1a2b3c", NumPeers: 8, SufficientPeers: 16, CreatedAt: at(40)},
+		{Addr: "0x1a2b3c", NumPeers: 4, SufficientPeers: 16, CreatedAt: at(30)},
+		{Addr: "0x1a2b3c", NumPeers: 12, SufficientPeers: 16, CreatedAt: at(20)},
+		{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16, CreatedAt: at(10)},
+	}
+
+	want := &PeerIncidents{
+		{
+			Addr:            "0x1a2b3c",
+			Start:           at(60),
+			End:             at(60),
+			Duration:        0,
+			MinPeers:        3,
+			SufficientPeers: 16,
+			Samples:         1,
+			Ongoing:         true,
+		},
+		{
+			Addr:            "0x1a2b3c",
+			Start:           at(20),
+			End:             at(50),
+			Duration:        1800,
+			MinPeers:        4,
+			SufficientPeers: 16,
+			Samples:         3,
+			Ongoing:         false,
+		},
+	}
+	got := NewPeerIncidents()
+	got.findPeerIncidents(p2pl)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.PeerIncidentsFindPeerIncidents() returned: %+v, wanted: %+v", got, want)
+	}
+
+	// test no incidents
+	got = NewPeerIncidents()
+	got.findPeerIncidents(&P2Ps{{NumPeers: 16, SufficientPeers: 16}})
+
+	if len(*got) != 0 {
+		t.Fatalf("data.PeerIncidentsFindPeerIncidents() returned: %v, wanted: %v", got, NewPeerIncidents())
+	}
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"time"
+)
+
+// Modified holds the last write time of the buckets behind a response, zero
+// if not yet written
+type Modified struct {
+	Time time.Time
+}
+
+func NewModified() *Modified {
+	return &Modified{}
+}
+
+func (md *Modified) GetUMBroadcastsModified() error {
+	return md.getModified(statsUptimesBroadcasts)
+}
+
+func (md *Modified) GetNumPeersModified() error {
+	return md.getModified(statsUptimesPeers)
+}
+
+// GetHealthsModified reads the write time of the broadcasts & peers of node
+// health and the blocks of its latest height
+func (md *Modified) GetHealthsModified() error {
+	return md.getModified(statsUptimesBroadcasts, statsUptimesPeers, statsBlocks)
+}
+
+// getModified keeps the latest write time of the buckets
+func (md *Modified) getModified(bkts ...string) error {
+	for _, bkt := range bkts {
+		// read data from db
+		b, err := readData([]byte(statsMeta), []byte(bkt))
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			continue // bucket not yet written
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, string(b))
+		if err != nil {
+			return wrapError(ErrInternal, err)
+		}
+		if t.After(md.Time) {
+			md.Time = t
+		}
+	}
+
+	return nil
+}
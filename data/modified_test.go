@@ -0,0 +1,64 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewModified(t *testing.T) {
+	want := &Modified{}
+	got := NewModified()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewModified() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestModifiedGetModified(t *testing.T) {
+	setTestDB(t)
+
+	// test bucket not yet written
+	md := NewModified()
+	if err := md.GetHealthsModified(); err != nil || !md.Time.IsZero() {
+		t.Fatalf("data.GetModified() returned: %v, %v, wanted zero time", md.Time, err)
+	}
+
+	// test bucket written
+	start := time.Now()
+	if err := writeData([]byte(statsUptimesBroadcasts), []byte("0x1a2b3c"), []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	md = NewModified()
+	if err := md.GetUMBroadcastsModified(); err != nil || md.Time.Before(start) {
+		t.Fatalf("data.GetModified() returned: %v, %v, wanted after: %v", md.Time, err, start)
+	}
+
+	// test other bucket unchanged
+	md = NewModified()
+	if err := md.GetNumPeersModified(); err != nil || !md.Time.IsZero() {
+		t.Fatalf("data.GetModified() returned: %v, %v, wanted zero time", md.Time, err)
+	}
+
+	// test latest of buckets
+	if err := writeRecords([]*record{{bkt: []byte(statsUptimesPeers), key: []byte("0x1a2b3c"), val: []byte("{}")}}); err != nil {
+		t.Fatal(err)
+	}
+	peers := NewModified()
+	if err := peers.GetNumPeersModified(); err != nil {
+		t.Fatal(err)
+	}
+	md = NewModified()
+	if err := md.GetHealthsModified(); err != nil || !md.Time.Equal(peers.Time) {
+		t.Fatalf("data.GetModified() returned: %v, %v, wanted: %v", md.Time, err, peers.Time)
+	}
+
+	// test block write alone changes health
+	if err := writeData([]byte(statsBlocks), []byte("2021-11-28T22:00:00Z"), []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	md = NewModified()
+	if err := md.GetHealthsModified(); err != nil || !md.Time.After(peers.Time) {
+		t.Fatalf("data.GetModified() returned: %v, %v, wanted after: %v", md.Time, err, peers.Time)
+	}
+}
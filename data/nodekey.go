@@ -0,0 +1,86 @@
+package data
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// NodeKeyName names the keys of requests signed by an edge node itself
+const NodeKeyName = "node"
+
+// recoverAddr returns the address of the key of a 65 byte r || s || v
+// signature of hash, v is 0, 1, 27 or 28
+func recoverAddr(hash, sig []byte) (string, error) {
+	if len(sig) != 65 {
+		return "", errors.New("error signature length")
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", errors.New("error signature recovery id")
+	}
+
+	// compact signatures are v || r || s of an uncompressed key
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", err
+	}
+
+	// address is the keccak256 of the public key, last 20 bytes in hex
+	return "0x" + hex.EncodeToString(keccak256(pub.SerializeUncompressed()[1:])[12:]), nil
+}
+
+// personalHash is the keccak256 of msg with the ethereum signed message
+// prefix, as signed by wallets
+func personalHash(msg []byte) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg))
+	return keccak256(append([]byte(prefix), msg...))
+}
+
+func keccak256(b []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// GetAPIKeyByNodeSignature sets k to an ingest key bound to addr if the node
+// key of addr signed msg, given as hex personal message signature, addr must
+// match one of the node address patterns, no patterns deny all nodes
+func (k *APIKey) GetAPIKeyByNodeSignature(addr string, msg []byte, sig string, nodes []string) error {
+	denied := Errorf(ErrPermissionDenied, "error not authorized")
+
+	// validate node address
+	if len(nodes) == 0 || !(&APIKey{Addresses: nodes}).AllowsAddr(addr) {
+		return denied
+	}
+
+	b, err := hex.DecodeString(strings.TrimPrefix(sig, "0x"))
+	if err != nil {
+		return denied
+	}
+
+	// recover signer address
+	got, err := recoverAddr(personalHash(msg), b)
+	if err != nil || !strings.EqualFold(got, addr) {
+		return denied
+	}
+
+	*k = APIKey{ID: got, Name: NodeKeyName, Scopes: []string{ScopeIngest}, Addresses: []string{got}}
+	return nil
+}
+
+// Stored reports whether the key is read from db, rather than set at build
+// time or recovered from a node signature
+func (k *APIKey) Stored() bool {
+	return !k.CreatedAt.IsZero()
+}
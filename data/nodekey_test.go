@@ -0,0 +1,79 @@
+package data
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// signTest signs hash with the key of priv as r || s || v
+func signTest(priv string, hash []byte) []byte {
+	b, _ := hex.DecodeString(priv)
+	compact := ecdsa.SignCompact(secp256k1.PrivKeyFromBytes(b), hash, false)
+
+	return append(compact[1:], compact[0])
+}
+
+func TestRecoverAddr(t *testing.T) {
+	// test personal message signature of a wallet, web3 accounts.sign("Some data")
+	sig, _ := hex.DecodeString("b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c")
+	hash := personalHash([]byte("Some data"))
+	if got := hex.EncodeToString(hash); got != "1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655" {
+		t.Fatalf("data.personalHash() returned: %v", got)
+	}
+	want := "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	if got, err := recoverAddr(hash, sig); err != nil || got != want {
+		t.Fatalf("data.recoverAddr() returned: %v, %v, wanted: %v", got, err, want)
+	}
+
+	// test v of 0 & 1
+	sig[64] -= 27
+	if got, err := recoverAddr(hash, sig); err != nil || got != want {
+		t.Fatalf("data.recoverAddr() returned: %v, %v, wanted: %v", got, err, want)
+	}
+
+	// test other hash recovers other address
+	if got, err := recoverAddr(personalHash([]byte("other")), sig); err == nil && got == want {
+		t.Fatalf("data.recoverAddr() returned: %v, wanted other address", got)
+	}
+
+	// test invalid signatures
+	if _, err := recoverAddr(hash, sig[:64]); err == nil {
+		t.Fatalf("data.recoverAddr() returned: %v, wanted error", err)
+	}
+	if _, err := recoverAddr(hash, make([]byte, 65)); err == nil {
+		t.Fatalf("data.recoverAddr() returned: %v, wanted error", err)
+	}
+}
+
+func TestAPIKeyGetAPIKeyByNodeSignature(t *testing.T) {
+	msg := []byte("POST\n/v1/heartbeats\n1638139791\n0123456789abcdef\ne3b0c44298fc1c149afbf4c8996fb924")
+	sig := "0x" + hex.EncodeToString(signTest("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", personalHash(msg)))
+	addr := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	nodes := []string{"0x2c7536e3*", "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"}
+
+	k := NewAPIKey()
+	if err := k.GetAPIKeyByNodeSignature(addr, msg, sig, nodes); err != nil {
+		t.Fatalf("data.GetAPIKeyByNodeSignature() returned error: %v", err)
+	}
+	if !k.HasScope(ScopeIngest) || k.HasScope(ScopeRead) || !k.AllowsAddr("0x2c7536e3605d9c16a7a3d7b1898e529396a65c23") || k.AllowsAddr("0x7e5f4552091a69125d5dfcb7b8c2659029395bdf") || k.Stored() {
+		t.Fatalf("data.GetAPIKeyByNodeSignature() returned: %v", k)
+	}
+
+	// test signature of other node & unknown nodes
+	for _, v := range []struct {
+		addr  string
+		nodes []string
+	}{
+		{"0x7e5f4552091a69125d5dfcb7b8c2659029395bdf", nodes},
+		{addr, []string{"0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"}},
+		{addr, nil},
+	} {
+		if err := NewAPIKey().GetAPIKeyByNodeSignature(v.addr, msg, sig, v.nodes); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.GetAPIKeyByNodeSignature(%q, %v) returned: %v, wanted: %v", v.addr, v.nodes, err, ErrPermissionDenied)
+		}
+	}
+}
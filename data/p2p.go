@@ -25,7 +25,24 @@ func (p2p *P2P) ToJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(p2p)
 }
 
+// p2pCSVHeader orders csv columns as json fields
+var p2pCSVHeader = []string{"address", "num_peers", "sufficient_peers", "created_at"}
+
+func (p2p *P2P) csvRecord() []string {
+	return []string{
+		p2p.Addr,
+		csvInt(int(p2p.NumPeers)),
+		csvInt(int(p2p.SufficientPeers)),
+		csvTime(p2p.CreatedAt),
+	}
+}
+
 func (p2p *P2P) CreateNumPeers() error {
+	// validate address
+	if p2p.Addr == "" {
+		return Errorf(ErrInvalidArgument, "error missing address")
+	}
+
 	// write to stats current table
 	if err := p2p.updateNumPeers(); err != nil {
 		return err
@@ -44,7 +61,7 @@ func (p2p *P2P) updateNumPeers() error {
 	k := p2p.Addr
 	v, err := json.Marshal(p2p)
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write data to db
@@ -60,7 +77,7 @@ func (p2p *P2P) createNumPeersByAddr() error {
 	k := p2p.CreatedAt.Format(time.RFC3339)
 	v, err := json.Marshal(p2p)
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write to db
@@ -85,9 +102,32 @@ func (p2p *P2Ps) ToJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(p2p)
 }
 
-func (p2pl *P2Ps) GetNumPeers() error {
+func (p2p *P2Ps) ToCSV(w io.Writer, fields ...string) error {
+	l := *p2p
+	return writeCSV(w, p2pCSVHeader, fields, len(l), func(i int) []string { return l[i].csvRecord() })
+}
+
+func (p2p *P2Ps) csvHeader() []string {
+	return p2pCSVHeader
+}
+
+func (p2p *P2Ps) csvDecode(b []byte) ([]string, error) {
+	v := NewP2P()
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, wrapError(ErrInternal, err)
+	}
+
+	return v.csvRecord(), nil
+}
+
+func (p2p *P2Ps) ToNDJSON(w io.Writer) error {
+	l := *p2p
+	return writeNDJSON(w, len(l), func(i int) interface{} { return l[i] })
+}
+
+func (p2pl *P2Ps) GetNumPeers(pg *Page) error {
 	// read data from db
-	buf, err := scanData([]byte(statsUptimesPeers))
+	buf, err := scanData([]byte(statsUptimesPeers), pg)
 	if err != nil {
 		return err
 	}
@@ -96,9 +136,9 @@ func (p2pl *P2Ps) GetNumPeers() error {
 	return p2pl.unmarshalData(buf)
 }
 
-func (p2pl *P2Ps) GetNumPeersByAddr(addr string) error {
+func (p2pl *P2Ps) GetNumPeersByAddr(addr string, pg *Page) error {
 	// read data from db
-	buf, err := scanNestedData([]byte(statsUptimesPeersByAddr), []byte(addr))
+	buf, err := scanNestedData([]byte(statsUptimesPeersByAddr), []byte(addr), pg)
 	if err != nil {
 		return err
 	}
@@ -107,14 +147,15 @@ func (p2pl *P2Ps) GetNumPeersByAddr(addr string) error {
 	return p2pl.unmarshalData(buf)
 }
 
-func (p2pl *P2Ps) GetNumPeersByAddrByRange(addr, min, max string) error {
+func (p2pl *P2Ps) GetNumPeersByAddrByRange(addr, min, max string, pg *Page) error {
 	// validate times
-	if err := validateTimes(min, max); err != nil {
+	min, max, err := normalizeTimes(min, max)
+	if err != nil {
 		return err
 	}
 
 	// read data from db
-	buf, err := scanNestedDataByRange([]byte(statsUptimesPeersByAddr), []byte(addr), []byte(min), []byte(max))
+	buf, err := scanNestedDataByRange([]byte(statsUptimesPeersByAddr), []byte(addr), []byte(min), []byte(max), pg)
 	if err != nil {
 		return err
 	}
@@ -123,9 +164,30 @@ func (p2pl *P2Ps) GetNumPeersByAddrByRange(addr, min, max string) error {
 	return p2pl.unmarshalData(buf)
 }
 
-func (p2pl *P2Ps) GetNumPeersByCluster(addrs string) error {
+func (p2pl *P2Ps) StreamNumPeers(pg *Page, sw StreamWriter) error {
+	// stream data from db
+	return streamData([]byte(statsUptimesPeers), nil, nil, nil, pg, sw)
+}
+
+func (p2pl *P2Ps) StreamNumPeersByAddr(addr string, pg *Page, sw StreamWriter) error {
+	// stream data from db
+	return streamData([]byte(statsUptimesPeersByAddr), []byte(addr), nil, nil, pg, sw)
+}
+
+func (p2pl *P2Ps) StreamNumPeersByAddrByRange(addr, min, max string, pg *Page, sw StreamWriter) error {
+	// validate times
+	min, max, err := normalizeTimes(min, max)
+	if err != nil {
+		return err
+	}
+
+	// stream data from db
+	return streamData([]byte(statsUptimesPeersByAddr), []byte(addr), []byte(min), rangeMax(max), pg, sw)
+}
+
+func (p2pl *P2Ps) GetNumPeersByCluster(addrs string, pg *Page) error {
 	// split addrs string
-	addrl := splitAddrs(addrs)
+	addrl := pageAddrs(splitAddrs(addrs), pg)
 
 	// read data from db
 	var buf [][]byte
@@ -134,6 +196,9 @@ func (p2pl *P2Ps) GetNumPeersByCluster(addrs string) error {
 		if err != nil {
 			return err // consider skip return and read as many as possible
 		}
+		if b == nil {
+			return Errorf(ErrNotFound, "error no data for address: %s", addr)
+		}
 
 		buf = append(buf, b)
 	}
@@ -146,7 +211,7 @@ func (p2pl *P2Ps) unmarshalData(buf [][]byte) error {
 	for _, b := range buf {
 		p2p := NewP2P()
 		if err := json.Unmarshal(b, p2p); err != nil {
-			return err
+			return wrapError(ErrInternal, err)
 		}
 
 		*p2pl = append(*p2pl, p2p)
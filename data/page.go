@@ -0,0 +1,115 @@
+package data
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+type Page struct {
+	Limit  int    // max records per page, 0 reads all records
+	Cursor string // opaque cursor of the previous page
+	Order  string // asc or desc, empty uses the list default order
+	Next   string // opaque cursor of the next page, empty on last page
+
+	// Match filters the records of lists keyed by address, nil matches all
+	Match func(addr string) bool
+
+	matchValue func(v []byte) bool // filters records by value, nil matches all
+}
+
+func NewPage(limit int, cursor, order string) (*Page, error) {
+	// validate cursor
+	if _, err := decodeCursor(cursor); err != nil {
+		return nil, Errorf(ErrInvalidArgument, "error invalid cursor: %s", cursor)
+	}
+
+	// validate order
+	if order != "" && order != OrderAsc && order != OrderDesc {
+		return nil, Errorf(ErrInvalidArgument, "error invalid order: %s", order)
+	}
+
+	return &Page{Limit: limit, Cursor: cursor, Order: order}, nil
+}
+
+// ascending reports whether to read in ascending order, def is the list default
+func (pg *Page) ascending(def bool) bool {
+	if pg == nil || pg.Order == "" {
+		return def
+	}
+
+	return pg.Order == OrderAsc
+}
+
+func (pg *Page) cursor() []byte {
+	if pg == nil || pg.Cursor == "" {
+		return nil
+	}
+
+	k, _ := decodeCursor(pg.Cursor) // validated in NewPage
+	return k
+}
+
+func (pg *Page) match(k []byte) bool {
+	return pg == nil || pg.Match == nil || pg.Match(string(k))
+}
+
+func (pg *Page) matchRecord(k, v []byte) bool {
+	return pg.match(k) && (pg == nil || pg.matchValue == nil || pg.matchValue(v))
+}
+
+// filter returns a page of all records with the address filter of pg
+func (pg *Page) filter() *Page {
+	if pg == nil {
+		return nil
+	}
+
+	return &Page{Match: pg.Match}
+}
+
+func (pg *Page) full(n int) bool {
+	return pg != nil && pg.Limit > 0 && n >= pg.Limit
+}
+
+// window returns the [lo, hi) bounds of the page over a list of n records,
+// computed lists use the offset of the next record as cursor
+func (pg *Page) window(n int) (int, int) {
+	if pg == nil {
+		return 0, n
+	}
+
+	lo := 0
+	if k := pg.cursor(); k != nil {
+		lo, _ = strconv.Atoi(string(k))
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	if lo > n {
+		lo = n
+	}
+
+	hi := n
+	if pg.Limit > 0 && lo+pg.Limit < n {
+		hi = lo + pg.Limit
+		pg.Next = encodeCursor([]byte(strconv.Itoa(hi)))
+	}
+
+	return lo, hi
+}
+
+func encodeCursor(k []byte) string {
+	return base64.RawURLEncoding.EncodeToString(k)
+}
+
+func decodeCursor(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	return base64.RawURLEncoding.DecodeString(s)
+}
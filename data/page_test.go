@@ -0,0 +1,76 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewPage(t *testing.T) {
+	want := &Page{Limit: 10, Cursor: "YQ", Order: OrderAsc}
+	got, err := NewPage(10, "YQ", "asc")
+	if err != nil {
+		t.Fatalf("data.NewPage() returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewPage() returned: %v, wanted: %v", got, want)
+	}
+
+	// test cursor error
+	if _, err := NewPage(10, "not a cursor!", ""); err == nil {
+		t.Fatalf("data.NewPage() returned: %v, wanted error", nil)
+	}
+
+	// test order error
+	if _, err := NewPage(10, "", "up"); err == nil {
+		t.Fatalf("data.NewPage() returned: %v, wanted error", nil)
+	}
+}
+
+func TestPageAscending(t *testing.T) {
+	var npg *Page
+	if got := npg.ascending(true); !got {
+		t.Fatalf("data.PageAscending() returned: %v, wanted: %v", got, true)
+	}
+
+	if got := (&Page{Order: OrderAsc}).ascending(false); !got {
+		t.Fatalf("data.PageAscending() returned: %v, wanted: %v", got, true)
+	}
+
+	if got := (&Page{Order: OrderDesc}).ascending(true); got {
+		t.Fatalf("data.PageAscending() returned: %v, wanted: %v", got, false)
+	}
+}
+
+func TestPageWindow(t *testing.T) {
+	pg := &Page{Limit: 4}
+	lo, hi := pg.window(10)
+	if lo != 0 || hi != 4 || pg.Next == "" {
+		t.Fatalf("data.PageWindow() returned: %d, %d, wanted: %d, %d", lo, hi, 0, 4)
+	}
+
+	pg = &Page{Limit: 4, Cursor: encodeCursor([]byte("8"))}
+	lo, hi = pg.window(10)
+	if lo != 8 || hi != 10 || pg.Next != "" {
+		t.Fatalf("data.PageWindow() returned: %d, %d, wanted: %d, %d", lo, hi, 8, 10)
+	}
+
+	// test nil page
+	var npg *Page
+	lo, hi = npg.window(10)
+	if lo != 0 || hi != 10 {
+		t.Fatalf("data.PageWindow() returned: %d, %d, wanted: %d, %d", lo, hi, 0, 10)
+	}
+}
+
+func TestEncodeCursor(t *testing.T) {
+	k := []byte("2021-11-28T22:49:51Z")
+	got, err := decodeCursor(encodeCursor(k))
+	if err != nil {
+		t.Fatalf("data.decodeCursor() returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, k) {
+		t.Fatalf("data.decodeCursor() returned: %s, wanted: %s", got, k)
+	}
+}
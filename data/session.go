@@ -2,9 +2,11 @@ package data
 
 import (
 	"bytes"
+	"errors"
 	"log"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +19,15 @@ const (
 	statsUptimesPeers           = "/stats/uptimes/peers"
 	statsUptimesPeersByAddr     = "/stats/uptimes/peers/addrs"
 	statsBlocks                 = "/stats/blocks"
+	statsMeta                   = "/stats/meta"
+	authKeys                    = "/auth/keys"
+	authSubjects                = "/auth/subjects"
+	authNonces                  = "/auth/nonces"
+	authShares                  = "/auth/shares"
+	authMeta                    = "/auth/meta"
+	authUsers                   = "/auth/users"
+	authSessions                = "/auth/sessions"
+	auditEntries                = "/audit/entries"
 )
 
 var (
@@ -51,11 +62,15 @@ func readData(bkt []byte, key []byte) ([]byte, error) {
 	db := DB.db
 	err := db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bkt)
+		if b == nil {
+			return nil // bucket not yet created
+		}
+
 		buf = b.Get(key)
 		return nil
 	})
 
-	return buf, err
+	return buf, wrapError(ErrInternal, err)
 }
 
 func readLastData(bkt []byte) ([]byte, error) {
@@ -68,7 +83,7 @@ func readLastData(bkt []byte) ([]byte, error) {
 		return err
 	})
 	if err != nil {
-		return buf, err
+		return buf, wrapError(ErrInternal, err)
 	}
 
 	// read data from db
@@ -78,31 +93,46 @@ func readLastData(bkt []byte) ([]byte, error) {
 		return nil
 	})
 
-	return buf, err
+	return buf, wrapError(ErrInternal, err)
 }
 
-func scanData(bkt []byte) ([][]byte, error) {
+func scanData(bkt []byte, pg *Page) ([][]byte, error) {
 	var buf [][]byte
 
 	db := DB.db
 	err := db.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket(bkt).Cursor()
+		b := tx.Bucket(bkt)
+		if b == nil {
+			return nil // bucket not yet created
+		}
+
+		buf = scanBucket(b, nil, nil, pg)
+		return nil
+	})
+
+	return buf, wrapError(ErrInternal, err)
+}
 
-		// // scan in ascending order
-		// for k, v := c.First(); k != nil; k, v = c.Next() {
-		// 	// fmt.Printf("key: %s\nvalue: %s\n", k, v)
-		// 	buf = append(buf, v)
-		// }
+// errStopScan ends a visitData scan early without error
+var errStopScan = errors.New("stop scan")
 
-		// scan in descending order
-		for k, v := c.Last(); k != nil; k, v = c.Prev() {
-			buf = append(buf, v)
+// visitData calls fn with the values of scanData as they are read, values are
+// only valid until fn returns
+func visitData(bkt []byte, pg *Page, fn func(v []byte) error) error {
+	db := DB.db
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bkt)
+		if b == nil {
+			return nil // bucket not yet created
 		}
 
-		return nil
+		return visitBucket(b, nil, nil, pg, fn)
 	})
+	if err == errStopScan {
+		return nil
+	}
 
-	return buf, err
+	return wrapError(ErrInternal, err)
 }
 
 func writeData(bkt, key, val []byte) error {
@@ -114,41 +144,142 @@ func writeData(bkt, key, val []byte) error {
 		return err
 	})
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write data to db
 	err = db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bkt)
-		err := b.Put(key, val)
-		return err
+		if err := b.Put(key, val); err != nil {
+			return err
+		}
+		return touchBucket(tx, bkt)
 	})
 
-	return err
+	return wrapError(ErrInternal, err)
 }
 
-func scanNestedData(bkt, nst []byte) ([][]byte, error) {
-	var buf [][]byte
+// updateData replaces the value of key with the result of fn in one
+// transaction, fn is called with nil if key does not exist
+func updateData(bkt, key []byte, fn func(v []byte) ([]byte, error)) error {
+	db := DB.db
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bkt)
+		if err != nil {
+			return err
+		}
+
+		v, err := fn(b.Get(key))
+		if err != nil {
+			return err
+		}
+		if err := b.Put(key, v); err != nil {
+			return err
+		}
+		return touchBucket(tx, bkt)
+	})
+
+	return wrapError(ErrInternal, err)
+}
 
+// updateDataKeys replaces the values of keys with the results of fn in one
+// transaction, keys that do not exist are skipped
+func updateDataKeys(bkt []byte, keys [][]byte, fn func(k, v []byte) ([]byte, error)) error {
 	db := DB.db
-	err := db.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket(bkt).Bucket(nst).Cursor()
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bkt)
+		if b == nil {
+			return nil // bucket not yet created
+		}
 
-		// // scan in ascending order
-		// for k, v := c.First(); k != nil; k, v = c.Next() {
-		//      // fmt.Printf("key: %s\nvalue: %s\n", k, v)
-		//      buf = append(buf, v)
-		// }
+		for _, k := range keys {
+			v := b.Get(k)
+			if v == nil {
+				continue
+			}
+
+			v, err := fn(k, v)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(k, v); err != nil {
+				return err
+			}
+		}
 
-		// scan in descending order
-		for k, v := c.Last(); k != nil; k, v = c.Prev() {
-			buf = append(buf, v)
+		return touchBucket(tx, bkt)
+	})
+
+	return wrapError(ErrInternal, err)
+}
+
+// deleteData deletes key if its value is val, in one transaction
+func deleteData(bkt, key, val []byte) error {
+	db := DB.db
+	err := db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bkt)
+		if b == nil || !bytes.Equal(b.Get(key), val) {
+			return nil
+		}
+
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+		return touchBucket(tx, bkt)
+	})
+
+	return wrapError(ErrInternal, err)
+}
+
+// insertData writes val unless key exists and reports whether it was
+// written, keys below expire are deleted first
+func insertData(bkt, key, val, expire []byte) (bool, error) {
+	var ok bool
+
+	db := DB.db
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bkt)
+		if err != nil {
+			return err
+		}
+
+		// delete expired keys
+		var del [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, expire) < 0; k, _ = c.Next() {
+			del = append(del, k)
+		}
+		for _, k := range del {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if b.Get(key) != nil {
+			return nil
+		}
+		ok = true
+		return b.Put(key, val)
+	})
+
+	return ok, wrapError(ErrInternal, err)
+}
+
+func scanNestedData(bkt, nst []byte, pg *Page) ([][]byte, error) {
+	var buf [][]byte
+
+	db := DB.db
+	err := db.View(func(tx *bolt.Tx) error {
+		b := nestedBucket(tx, bkt, nst)
+		if b == nil {
+			return nil // bucket not yet created
 		}
 
+		buf = scanBucket(b, nil, nil, pg)
 		return nil
 	})
 
-	return buf, err
+	return buf, wrapError(ErrInternal, err)
 }
 
 func writeNestedData(bkt, nst, key, val []byte) error {
@@ -165,20 +296,73 @@ func writeNestedData(bkt, nst, key, val []byte) error {
 		return err
 	})
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write data to db
 	err = db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bkt).Bucket(nst)
-		err := b.Put(key, val)
-		return err
+		if err := b.Put(key, val); err != nil {
+			return err
+		}
+		return touchBucket(tx, bkt)
+	})
+
+	return wrapError(ErrInternal, err)
+}
+
+type record struct {
+	bkt []byte
+	nst []byte // optional nested bucket
+	key []byte
+	val []byte
+}
+
+func writeRecords(recs []*record) error {
+	db := DB.db
+
+	// write all records in one transaction
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, rec := range recs {
+			// create bucket if not exists
+			b, err := tx.CreateBucketIfNotExists(rec.bkt)
+			if err != nil {
+				return err
+			}
+
+			// create nested bucket if not exists
+			if rec.nst != nil {
+				b, err = b.CreateBucketIfNotExists(rec.nst)
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := b.Put(rec.key, rec.val); err != nil {
+				return err
+			}
+			if err := touchBucket(tx, rec.bkt); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
 
-	return err
+	return wrapError(ErrInternal, err)
+}
+
+// touchBucket records the last write time of a bucket in the meta bucket
+func touchBucket(tx *bolt.Tx, bkt []byte) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(statsMeta))
+	if err != nil {
+		return err
+	}
+
+	return b.Put(bkt, []byte(time.Now().UTC().Format(time.RFC3339Nano)))
 }
 
-func scanNestedDataByRange(bkt, nst, min, max []byte) ([][]byte, error) {
+func scanNestedDataByRange(bkt, nst, min, max []byte, pg *Page) ([][]byte, error) {
 	var buf [][]byte
 
 	if bytes.Equal(max, []byte("")) { // possible to read c.Last()
@@ -187,26 +371,19 @@ func scanNestedDataByRange(bkt, nst, min, max []byte) ([][]byte, error) {
 
 	db := DB.db
 	err := db.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket(bkt).Bucket(nst).Cursor()
-
-		// // scan in ascending order
-		// for k, v := c.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() { // < if [min,max)
-		//         buf = append(buf, v)
-		// }
-
-		// scan in descending order
-		k, v := c.Seek(max)
-		for k, v = c.Prev(); k != nil && bytes.Compare(k, min) >= 0; k, v = c.Prev() { // > if (min,max]
-			buf = append(buf, v)
+		b := nestedBucket(tx, bkt, nst)
+		if b == nil {
+			return nil // bucket not yet created
 		}
 
+		buf = scanBucket(b, min, max, pg)
 		return nil
 	})
 
-	return buf, err
+	return buf, wrapError(ErrInternal, err)
 }
 
-func scanDataByRange(bkt, min, max []byte) ([][]byte, error) {
+func scanDataByRange(bkt, min, max []byte, pg *Page) ([][]byte, error) {
 	var buf [][]byte
 
 	if bytes.Equal(max, []byte("")) { // possible to read c.Last()
@@ -215,23 +392,163 @@ func scanDataByRange(bkt, min, max []byte) ([][]byte, error) {
 
 	db := DB.db
 	err := db.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket(bkt).Cursor()
+		b := tx.Bucket(bkt)
+		if b == nil {
+			return nil // bucket not yet created
+		}
 
-		// // scan in ascending order
-		// for k, v := c.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() { // < if [min,max)
-		//         buf = append(buf, v)
-		// }
+		buf = scanBucket(b, min, max, pg)
+		return nil
+	})
+
+	return buf, wrapError(ErrInternal, err)
+}
 
-		// scan in descending order
-		k, v := c.Seek(max)
-		for k, v = c.Prev(); k != nil && bytes.Compare(k, min) >= 0; k, v = c.Prev() { // > if (min,max]
-			buf = append(buf, v)
+func nestedBucket(tx *bolt.Tx, bkt, nst []byte) *bolt.Bucket {
+	root := tx.Bucket(bkt)
+	if root == nil {
+		return nil
+	}
+
+	return root.Bucket(nst)
+}
+
+// scanBucket reads values in key order within [min,max), nil bounds are open,
+// and continues after the page cursor key if set
+func scanBucket(b *bolt.Bucket, min, max []byte, pg *Page) [][]byte {
+	var buf [][]byte
+	visitBucket(b, min, max, pg, func(v []byte) error {
+		buf = append(buf, v)
+		return nil
+	})
+
+	return buf
+}
+
+// visitBucket calls fn with the values of scanBucket as they are read, values
+// are only valid until the transaction ends
+func visitBucket(b *bolt.Bucket, min, max []byte, pg *Page, fn func(v []byte) error) error {
+	var k, v, last []byte
+	var n int
+
+	c := b.Cursor()
+	cur := pg.cursor()
+
+	// scan in ascending order
+	if pg.ascending(false) {
+		switch {
+		case cur != nil:
+			if k, v = c.Seek(cur); bytes.Equal(k, cur) {
+				k, v = c.Next() // skip cursor key
+			}
+		case min != nil:
+			k, v = c.Seek(min)
+		default:
+			k, v = c.First()
+		}
+		for ; k != nil && (max == nil || bytes.Compare(k, max) < 0); k, v = c.Next() { // <= if [min,max]
+			if !pg.matchRecord(k, v) {
+				continue
+			}
+			if pg.full(n) {
+				pg.Next = encodeCursor(last) // next page starts after last read key
+				break
+			}
+
+			if err := fn(v); err != nil {
+				return err
+			}
+			n++
+			last = k
 		}
 
+		return nil
+	}
+
+	// set start key, cursor key is always below max
+	start := max
+	if cur != nil {
+		start = cur
+	}
+
+	// scan in descending order
+	if start == nil {
+		k, v = c.Last()
+	} else {
+		c.Seek(start)
+		k, v = c.Prev()
+	}
+	for ; k != nil && bytes.Compare(k, min) >= 0; k, v = c.Prev() { // > if (min,max]
+		if !pg.matchRecord(k, v) {
+			continue
+		}
+		if pg.full(n) {
+			pg.Next = encodeCursor(last) // next page starts after last read key
+			break
+		}
+
+		if err := fn(v); err != nil {
+			return err
+		}
+		n++
+		last = k
+	}
+
+	return nil
+}
+
+// StreamWriter receives the raw json records of a scan as they are read from
+// the db, Head is called once with the page before the first record
+type StreamWriter interface {
+	Head(pg *Page) error
+	Record(b []byte) error
+}
+
+// streamData scans like scanNestedDataByRange without copying values out of
+// the db, nst is optional & a nil max is open
+func streamData(bkt, nst, min, max []byte, pg *Page, sw StreamWriter) error {
+	db := DB.db
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bkt)
+		if b != nil && nst != nil {
+			b = b.Bucket(nst)
+		}
+		if b == nil {
+			return sw.Head(pg) // bucket not yet created
+		}
+
+		// walk the page once, its values point into the db until the
+		// transaction ends and the walk finds the next page cursor
+		var vals [][]byte
+		if err := visitBucket(b, min, max, pg, func(v []byte) error {
+			vals = append(vals, v)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := sw.Head(pg); err != nil {
+			return err
+		}
+
+		// write records in the same transaction
+		for _, v := range vals {
+			if err := sw.Record(v); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
-	return buf, err
+	return wrapError(ErrInternal, err)
+}
+
+// rangeMax defaults an empty range end to now
+func rangeMax(max string) []byte {
+	if max == "" {
+		return []byte(time.Now().UTC().Format(time.RFC3339))
+	}
+
+	return []byte(max)
 }
 
 func splitAddrs(addrs string) []string {
@@ -261,18 +578,77 @@ func splitAddrs(addrs string) []string {
 	return l
 }
 
-func validateTimes(min, max string) error {
+func pageAddrs(addrl []string, pg *Page) []string {
+	// filter addrs
+	var l []string
+	for _, v := range addrl {
+		if pg.match([]byte(v)) {
+			l = append(l, v)
+		}
+	}
+	addrl = l
+
+	// addrs are sorted in ascending order
+	asc := pg.ascending(true)
+	if !asc {
+		l := make([]string, len(addrl))
+		for i, v := range addrl {
+			l[len(addrl)-1-i] = v
+		}
+		addrl = l
+	}
+
+	// continue after cursor addr
+	if k := pg.cursor(); k != nil {
+		i := 0
+		for ; i < len(addrl); i++ {
+			if c := strings.Compare(addrl[i], string(k)); (asc && c > 0) || (!asc && c < 0) {
+				break
+			}
+		}
+		addrl = addrl[i:]
+	}
+
+	if pg.full(len(addrl) - 1) {
+		addrl = addrl[:pg.Limit]
+		pg.Next = encodeCursor([]byte(addrl[pg.Limit-1]))
+	}
+
+	return addrl
+}
+
+func parseTime(s string) (time.Time, error) {
+	// parse unix seconds
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(v, 0).UTC(), nil
+	}
+
+	// parse rfc3339 time
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, err
+	}
+
+	return t.UTC(), nil
+}
+
+func normalizeTimes(min, max string) (string, string, error) {
 	// parse min time
-	_, err := time.Parse(time.RFC3339, min)
+	vmin, err := parseTime(min)
 	if err != nil {
-		return err
+		return "", "", Errorf(ErrInvalidArgument, "error with min time: %s", min)
 	}
+	min = vmin.Format(time.RFC3339)
 
 	// parse max time
-	_, err = time.Parse(time.RFC3339, max)
-	if err != nil && max != "" {
-		return err
+	if max == "" {
+		return min, max, nil
 	}
+	vmax, err := parseTime(max)
+	if err != nil {
+		return "", "", Errorf(ErrInvalidArgument, "error with max time: %s", max)
+	}
+	max = vmax.Format(time.RFC3339)
 
-	return nil
+	return min, max, nil
 }
@@ -1,12 +1,23 @@
 package data
 
 import (
+	"errors"
 	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 )
 
+func setTestDB(t *testing.T) {
+	db := DB
+	DB = NewBoltDB(filepath.Join(t.TempDir(), "test.db"))
+
+	t.Cleanup(func() {
+		DB.Close()
+		DB = db
+	})
+}
+
 func TestNewBoltDB(t *testing.T) {
 	// test variables
 	var tmp string
@@ -43,7 +54,86 @@ func TestReadData(t *testing.T) {}
 
 func TestReadLastData(t *testing.T) {}
 
-func TestScanData(t *testing.T) {}
+func TestScanData(t *testing.T) {
+	setTestDB(t)
+
+	bkt := []byte("/test")
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		if err := writeData(bkt, []byte(k), []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// test pages in descending order
+	want := [][]string{{"e", "d"}, {"c", "b"}, {"a"}}
+	pg := &Page{Limit: 2}
+	for i, w := range want {
+		buf, err := scanData(bkt, pg)
+		if err != nil {
+			t.Fatalf("data.scanData() returned error: %v", err)
+		}
+
+		var got []string
+		for _, v := range buf {
+			got = append(got, string(v))
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Fatalf("data.scanData() page %d returned: %v, wanted: %v", i, got, w)
+		}
+
+		pg = &Page{Limit: 2, Cursor: pg.Next}
+	}
+	if pg.Cursor != "" {
+		t.Fatalf("data.scanData() returned next cursor: %v, wanted: %v", pg.Cursor, "")
+	}
+
+	// test pages in ascending order
+	want = [][]string{{"a", "b", "c"}, {"d", "e"}}
+	pg = &Page{Limit: 3, Order: OrderAsc}
+	for i, w := range want {
+		buf, err := scanData(bkt, pg)
+		if err != nil {
+			t.Fatalf("data.scanData() returned error: %v", err)
+		}
+
+		var got []string
+		for _, v := range buf {
+			got = append(got, string(v))
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Fatalf("data.scanData() page %d returned: %v, wanted: %v", i, got, w)
+		}
+
+		pg = &Page{Limit: 3, Cursor: pg.Next, Order: OrderAsc}
+	}
+
+	// test pages of matched keys
+	want = [][]string{{"e", "c"}, {"a"}}
+	match := func(k string) bool { return k != "b" && k != "d" }
+	pg = &Page{Limit: 2, Match: match}
+	for i, w := range want {
+		buf, err := scanData(bkt, pg)
+		if err != nil {
+			t.Fatalf("data.scanData() returned error: %v", err)
+		}
+
+		var got []string
+		for _, v := range buf {
+			got = append(got, string(v))
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Fatalf("data.scanData() page %d returned: %v, wanted: %v", i, got, w)
+		}
+
+		pg = &Page{Limit: 2, Cursor: pg.Next, Match: match}
+	}
+
+	// test missing bucket
+	buf, err := scanData([]byte("/missing"), nil)
+	if err != nil || buf != nil {
+		t.Fatalf("data.scanData() returned: %v, %v, wanted: %v, %v", buf, err, nil, nil)
+	}
+}
 
 func TestWriteData(t *testing.T) {}
 
@@ -53,7 +143,90 @@ func TestWriteNestedData(t *testing.T) {}
 
 func TestScanNestedDataByRange(t *testing.T) {}
 
-func TestScanDataByRange(t *testing.T) {}
+type testStream struct {
+	next string
+	recs []string
+}
+
+func (ts *testStream) Head(pg *Page) error {
+	if len(ts.recs) > 0 {
+		return errors.New("head after records")
+	}
+	ts.next = pg.Next
+	return nil
+}
+
+func (ts *testStream) Record(b []byte) error {
+	ts.recs = append(ts.recs, string(b))
+	return nil
+}
+
+func TestStreamData(t *testing.T) {
+	setTestDB(t)
+
+	bkt := []byte("/test")
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		if err := writeData(bkt, []byte(k), []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// test next cursor known at head
+	ts := &testStream{}
+	pg := &Page{Limit: 2}
+	if err := streamData(bkt, nil, nil, nil, pg, ts); err != nil {
+		t.Fatalf("data.streamData() returned error: %v", err)
+	}
+	if want := []string{"e", "d"}; !reflect.DeepEqual(ts.recs, want) || ts.next != encodeCursor([]byte("d")) {
+		t.Fatalf("data.streamData() returned: %v, %v, wanted: %v, %v", ts.recs, ts.next, want, encodeCursor([]byte("d")))
+	}
+
+	// test range on last page
+	ts = &testStream{}
+	pg = &Page{Limit: 2, Cursor: encodeCursor([]byte("d"))}
+	if err := streamData(bkt, nil, []byte("c"), []byte("z"), pg, ts); err != nil {
+		t.Fatalf("data.streamData() returned error: %v", err)
+	}
+	if want := []string{"c"}; !reflect.DeepEqual(ts.recs, want) || ts.next != "" {
+		t.Fatalf("data.streamData() returned: %v, %v, wanted: %v", ts.recs, ts.next, want)
+	}
+
+	// test bucket not yet created
+	ts = &testStream{}
+	if err := streamData([]byte("/none"), []byte("0x1a2b3c"), nil, nil, &Page{Limit: 2}, ts); err != nil || len(ts.recs) != 0 {
+		t.Fatalf("data.streamData() returned: %v, %v, wanted no records", ts.recs, err)
+	}
+}
+
+func TestScanDataByRange(t *testing.T) {
+	setTestDB(t)
+
+	bkt := []byte("/test")
+	for _, k := range []string{"2021-11-28T22:00:00Z", "2021-11-28T22:10:00Z", "2021-11-28T22:20:00Z", "2021-11-28T22:30:00Z"} {
+		if err := writeData(bkt, []byte(k), []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// test range with page
+	pg := &Page{Limit: 1}
+	buf, err := scanDataByRange(bkt, []byte("2021-11-28T22:10:00Z"), []byte("2021-11-28T22:30:00Z"), pg)
+	if err != nil {
+		t.Fatalf("data.scanDataByRange() returned error: %v", err)
+	}
+	if len(buf) != 1 || string(buf[0]) != "2021-11-28T22:20:00Z" || pg.Next == "" {
+		t.Fatalf("data.scanDataByRange() returned: %s, next: %v", buf, pg.Next)
+	}
+
+	pg = &Page{Limit: 1, Cursor: pg.Next}
+	buf, err = scanDataByRange(bkt, []byte("2021-11-28T22:10:00Z"), []byte("2021-11-28T22:30:00Z"), pg)
+	if err != nil {
+		t.Fatalf("data.scanDataByRange() returned error: %v", err)
+	}
+	if len(buf) != 1 || string(buf[0]) != "2021-11-28T22:10:00Z" || pg.Next != "" {
+		t.Fatalf("data.scanDataByRange() returned: %s, next: %v", buf, pg.Next)
+	}
+}
 
 func TestSplitAddrs(t *testing.T) {
 	l := "0xabc12345,0xdef67890,0xbcd45678,0xbcd45678,0xcdf28465"
@@ -65,7 +238,57 @@ func TestSplitAddrs(t *testing.T) {
 	}
 }
 
-func TestValidateTimes(t *testing.T) {
+func TestPageAddrs(t *testing.T) {
+	addrl := []string{"0xabc12345", "0xbcd45678", "0xcdf28465", "0xdef67890"}
+
+	pg := &Page{Limit: 3}
+	got := pageAddrs(addrl, pg)
+	want := []string{"0xabc12345", "0xbcd45678", "0xcdf28465"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.pageAddrs() returned: %v, wanted: %v", got, want)
+	}
+
+	pg = &Page{Limit: 3, Cursor: pg.Next}
+	got = pageAddrs(addrl, pg)
+	want = []string{"0xdef67890"}
+	if !reflect.DeepEqual(got, want) || pg.Next != "" {
+		t.Fatalf("data.pageAddrs() returned: %v, next: %v, wanted: %v", got, pg.Next, want)
+	}
+
+	// test matched addrs
+	pg = &Page{Limit: 3, Match: func(addr string) bool { return addr != "0xbcd45678" }}
+	got = pageAddrs(addrl, pg)
+	want = []string{"0xabc12345", "0xcdf28465", "0xdef67890"}
+	if !reflect.DeepEqual(got, want) || pg.Next != "" {
+		t.Fatalf("data.pageAddrs() returned: %v, next: %v, wanted: %v", got, pg.Next, want)
+	}
+
+	// test descending order
+	pg = &Page{Limit: 2, Order: OrderDesc, Cursor: encodeCursor([]byte("0xcdf28465"))}
+	got = pageAddrs(addrl, pg)
+	want = []string{"0xbcd45678", "0xabc12345"}
+	if !reflect.DeepEqual(got, want) || pg.Next != "" {
+		t.Fatalf("data.pageAddrs() returned: %v, next: %v, wanted: %v", got, pg.Next, want)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want, _ := time.Parse(time.RFC3339, "2021-11-28T22:49:51Z")
+
+	// test unix seconds
+	got, err := parseTime("1638139791")
+	if err != nil || !got.Equal(want) {
+		t.Fatalf("data.parseTime() returned: %v, %v, wanted: %v", got, err, want)
+	}
+
+	// test rfc3339 with offset
+	got, err = parseTime("2021-11-29T00:49:51+02:00")
+	if err != nil || !got.Equal(want) || got.Location() != time.UTC {
+		t.Fatalf("data.parseTime() returned: %v, %v, wanted: %v", got, err, want)
+	}
+}
+
+func TestNormalizeTimes(t *testing.T) {
 	var min string
 	var max string
 	var err error
@@ -76,26 +299,32 @@ func TestValidateTimes(t *testing.T) {
 	min = now.Format(time.RFC3339)
 	max = now.Add(time.Hour * 2).Format(time.RFC3339)
 
-	err = validateTimes(min, max)
+	_, _, err = normalizeTimes(min, max)
 	if err != nil {
-		t.Fatalf("data.validateTimes() returned error: %v", err)
+		t.Fatalf("data.normalizeTimes() returned error: %v", err)
+	}
+
+	// test unix times
+	gmin, gmax, err := normalizeTimes("1638139791", "")
+	if err != nil || gmin != "2021-11-28T22:49:51Z" || gmax != "" {
+		t.Fatalf("data.normalizeTimes() returned: %v, %v, %v", gmin, gmax, err)
 	}
 
 	// test min time error
 	min = now.Format(time.RFC1123)
 	max = now.Add(time.Hour * 2).Format(time.RFC3339)
 
-	err = validateTimes(min, max)
+	_, _, err = normalizeTimes(min, max)
 	if err == nil {
-		t.Fatalf("data.validateTimes() returned: %v, wanted error", nil)
+		t.Fatalf("data.normalizeTimes() returned: %v, wanted error", nil)
 	}
 
 	// test max time error
 	min = now.Format(time.RFC3339)
 	max = now.Add(time.Hour * 2).String()
 
-	err = validateTimes(min, max)
+	_, _, err = normalizeTimes(min, max)
 	if err == nil {
-		t.Fatalf("data.validateTimes() returned: %v, wanted error", nil)
+		t.Fatalf("data.normalizeTimes() returned: %v, wanted error", nil)
 	}
 }
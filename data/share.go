@@ -0,0 +1,278 @@
+package data
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ShareKeyName names the keys of requests with a share token
+const ShareKeyName = "share"
+
+// Share grants read access to the routes of some addresses until it expires,
+// its token carries the signed share so only revocation is read from db
+type Share struct {
+	ID        string     `json:"id"`
+	Addresses []string   `json:"addresses"`
+	Routes    []string   `json:"routes,omitempty"` // route names, empty allows all read routes
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// shareClaims are signed into a share token
+type shareClaims struct {
+	ID        string   `json:"id"`
+	Addresses []string `json:"addresses"`
+	Routes    []string `json:"routes,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+func NewShare() *Share {
+	return &Share{}
+}
+
+func (sh *Share) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(sh)
+}
+
+func (sh *Share) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(sh)
+}
+
+// ShareToken is a share with its token, returned once on create
+type ShareToken struct {
+	Share
+	Token string `json:"token"`
+}
+
+func (st *ShareToken) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(st)
+}
+
+// CreateShare stores a share of the addresses, routes & expiry of sh created
+// by key id and returns its token
+func (sh *Share) CreateShare(createdBy string) (string, error) {
+	// validate share
+	if len(sh.Addresses) == 0 {
+		return "", Errorf(ErrInvalidArgument, "error missing share addresses")
+	}
+	for _, a := range sh.Addresses {
+		if a == "" || strings.ContainsAny(a, `*?[\`) {
+			return "", Errorf(ErrInvalidArgument, "error with share address: %s", a)
+		}
+	}
+	if !sh.ExpiresAt.After(time.Now()) {
+		return "", Errorf(ErrInvalidArgument, "error share expiry in the past")
+	}
+
+	id, err := randomToken(8, hex.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	*sh = Share{
+		ID:        id,
+		Addresses: sh.Addresses,
+		Routes:    sh.Routes,
+		ExpiresAt: sh.ExpiresAt.UTC().Truncate(time.Second),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	// sign claims
+	token, err := sh.sign()
+	if err != nil {
+		return "", err
+	}
+
+	if err := sh.updateShare(); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (sh *Share) updateShare() error {
+	// set key & value
+	v, err := json.Marshal(sh)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// write data to db
+	return writeData([]byte(authShares), []byte(sh.ID), v)
+}
+
+// GetShareByToken reads the share of a token, forged, expired & revoked
+// tokens are all denied alike
+func (sh *Share) GetShareByToken(token string) error {
+	denied := Errorf(ErrPermissionDenied, "error not authorized")
+
+	// split token
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return denied
+	}
+	payload, sig := token[:i], token[i+1:]
+
+	// compare signatures in constant time
+	secret, err := shareSecret()
+	if err != nil {
+		return err
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signMessage(string(secret), []byte(payload))) {
+		return denied
+	}
+
+	// unmarshal claims
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return denied
+	}
+	c := &shareClaims{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return denied
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return denied
+	}
+
+	// read revocation from db
+	stored := NewShare()
+	if err := stored.GetShare(c.ID); errors.Is(err, ErrNotFound) {
+		return denied
+	} else if err != nil {
+		return err
+	}
+	if stored.RevokedAt != nil {
+		return denied
+	}
+
+	*sh = *stored
+	return nil
+}
+
+// GetShare reads a share by id
+func (sh *Share) GetShare(id string) error {
+	// read data from db
+	b, err := readData([]byte(authShares), []byte(id))
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return Errorf(ErrNotFound, "error no share for id: %s", id)
+	}
+
+	// unmarshal data to struct
+	return wrapError(ErrInternal, json.Unmarshal(b, sh))
+}
+
+// RevokeShare denies all further requests with the token of share id
+func (sh *Share) RevokeShare(id string) error {
+	err := updateData([]byte(authShares), []byte(id), func(v []byte) ([]byte, error) {
+		if v == nil {
+			return nil, Errorf(ErrNotFound, "error no share for id: %s", id)
+		}
+
+		// unmarshal data to struct
+		*sh = Share{}
+		if err := json.Unmarshal(v, sh); err != nil {
+			return nil, err
+		}
+
+		if sh.RevokedAt == nil {
+			now := time.Now().UTC()
+			sh.RevokedAt = &now
+		}
+
+		return json.Marshal(sh)
+	})
+
+	return err
+}
+
+// APIKey is the read only key of requests with the share token
+func (sh *Share) APIKey() *APIKey {
+	return &APIKey{ID: ShareKeyName + ":" + sh.ID, Name: ShareKeyName, Scopes: []string{ScopeRead}, Addresses: sh.Addresses, Routes: sh.Routes}
+}
+
+func (sh *Share) sign() (string, error) {
+	secret, err := shareSecret()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(&shareClaims{sh.ID, sh.Addresses, sh.Routes, sh.ExpiresAt.Unix()})
+	if err != nil {
+		return "", wrapError(ErrInternal, err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signMessage(string(secret), []byte(payload))), nil
+}
+
+type Shares []*Share
+
+func NewShares() *Shares {
+	return &Shares{}
+}
+
+func (shl *Shares) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(shl)
+}
+
+func (shl *Shares) GetShares() error {
+	// read data from db
+	buf, err := scanData([]byte(authShares), &Page{Order: OrderAsc})
+	if err != nil {
+		return err
+	}
+
+	// unmarshal data to struct
+	for _, b := range buf {
+		sh := NewShare()
+		if err := json.Unmarshal(b, sh); err != nil {
+			return wrapError(ErrInternal, err)
+		}
+
+		*shl = append(*shl, sh)
+	}
+
+	return nil
+}
+
+// shareSecret reads the share signing secret, created on first use
+func shareSecret() ([]byte, error) {
+	return authSecret("share_secret")
+}
+
+// authSecret reads the signing secret of name, created on first use
+func authSecret(name string) ([]byte, error) {
+	key := []byte(name)
+
+	// read data from db
+	b, err := readData([]byte(authMeta), key)
+	if err != nil || b != nil {
+		return append([]byte(nil), b...), err
+	}
+
+	// create secret if not exists, concurrent creates keep the first one
+	secret, err := randomToken(32, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := insertData([]byte(authMeta), key, []byte(secret), nil); err != nil {
+		return nil, err
+	}
+
+	b, err = readData([]byte(authMeta), key)
+	return append([]byte(nil), b...), err
+}
@@ -0,0 +1,78 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewShare(t *testing.T) {
+	want := &Share{}
+	got := NewShare()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewShare() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestShareCreateShare(t *testing.T) {
+	setTestDB(t)
+
+	// test invalid shares
+	for _, sh := range []*Share{
+		{ExpiresAt: time.Now().Add(time.Hour)},
+		{Addresses: []string{"0xabc*"}, ExpiresAt: time.Now().Add(time.Hour)},
+		{Addresses: []string{"0xabc"}, ExpiresAt: time.Now().Add(-time.Hour)},
+	} {
+		if _, err := sh.CreateShare("0a1b2c3d"); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("data.CreateShare() returned: %v, wanted: %v", err, ErrInvalidArgument)
+		}
+	}
+
+	// test created share by token
+	sh := &Share{Addresses: []string{"0xabc"}, Routes: []string{"GetHealthByAddr"}, ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := sh.CreateShare("0a1b2c3d")
+	if err != nil {
+		t.Fatalf("data.CreateShare() returned error: %v", err)
+	}
+
+	got := NewShare()
+	if err := got.GetShareByToken(token); err != nil || got.ID != sh.ID || got.CreatedBy != "0a1b2c3d" {
+		t.Fatalf("data.GetShareByToken() returned: %v, %v, wanted: %v", got, err, sh)
+	}
+	k := got.APIKey()
+	if !k.AllowsAddr("0xabc") || k.AllowsAddr("0xdef") || !k.AllowsRoute("GetHealthByAddr") || k.AllowsRoute("GetHealths") || k.HasScope(ScopeIngest) {
+		t.Fatalf("data.APIKey() returned: %v", k)
+	}
+}
+
+func TestShareGetShareByToken(t *testing.T) {
+	setTestDB(t)
+
+	sh := &Share{Addresses: []string{"0xabc"}, ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := sh.CreateShare("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test forged tokens
+	for _, v := range []string{"", "nodot", token + "x", "e30." + token[len(token)-10:]} {
+		if err := NewShare().GetShareByToken(v); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.GetShareByToken(%q) returned: %v, wanted: %v", v, err, ErrPermissionDenied)
+		}
+	}
+
+	// test revoked token
+	if err := NewShare().RevokeShare(sh.ID); err != nil {
+		t.Fatalf("data.RevokeShare() returned error: %v", err)
+	}
+	if err := NewShare().GetShareByToken(token); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.GetShareByToken() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+
+	shl := NewShares()
+	if err := shl.GetShares(); err != nil || len(*shl) != 1 || (*shl)[0].RevokedAt == nil {
+		t.Fatalf("data.GetShares() returned: %v, %v", shl, err)
+	}
+}
@@ -0,0 +1,113 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GetAPIKeyBySignature reads the key of id whose signing key signed msg with
+// HMAC-SHA256, invalid signatures of unknown, expired & revoked keys are all
+// denied alike
+func (k *APIKey) GetAPIKeyBySignature(id string, msg []byte, sig string) error {
+	denied := Errorf(ErrPermissionDenied, "error not authorized")
+
+	// read data from db
+	b, err := readData([]byte(authKeys), []byte(id))
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return denied
+	}
+
+	// unmarshal data to struct
+	stored := NewAPIKey()
+	if err := json.Unmarshal(b, stored); err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// compare signatures in constant time, the rotated secret until its grace ends
+	now := time.Now()
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return denied
+	}
+	key, err := signingKey(stored.Hash)
+	if err != nil {
+		return err
+	}
+	prevKey, err := signingKey(stored.PrevHash)
+	if err != nil {
+		return err
+	}
+	ok := hmac.Equal(got, signMessage(key, msg))
+	prev := hmac.Equal(got, signMessage(prevKey, msg))
+	if !ok && !(prev && stored.PrevHash != "" && stored.PrevExpiresAt != nil && stored.PrevExpiresAt.After(now)) {
+		return denied
+	}
+	if stored.ExpiresAt != nil && !stored.ExpiresAt.After(now) {
+		return denied
+	}
+	if stored.RevokedAt != nil {
+		return denied
+	}
+
+	*k = *stored
+	k.redact()
+	return nil
+}
+
+// SigningKey returns the signing key of a token, returned once with the token
+// on create & rotate
+func SigningKey(token string) (string, error) {
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return "", Errorf(ErrInvalidArgument, "error with token")
+	}
+
+	return signingKey(hashSecret(token[i+1:]))
+}
+
+// signingKey derives the signing key of a secret hash with the server signing
+// secret, so the hashes stored in db cannot sign requests
+func signingKey(hash string) (string, error) {
+	secret, err := authSecret("signing_secret")
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(signMessage(string(secret), []byte(hash))), nil
+}
+
+// UseNonce records the nonce of a request signed at ts, nonces are kept for
+// window after ts and denied if used again
+func (k *APIKey) UseNonce(nonce string, ts time.Time, window time.Duration) error {
+	key := []byte(nonceKey(ts, k.ID, nonce))
+	expire := []byte(nonceKey(time.Now().Add(-window), "", ""))
+
+	ok, err := insertData([]byte(authNonces), key, []byte(ts.UTC().Format(time.RFC3339)), expire)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return Errorf(ErrPermissionDenied, "error nonce already used")
+	}
+
+	return nil
+}
+
+// nonceKey sorts nonces by time to delete expired ones from the front
+func nonceKey(ts time.Time, id, nonce string) string {
+	return fmt.Sprintf("%020d/%s/%s", ts.Unix(), id, nonce)
+}
+
+func signMessage(key string, msg []byte) []byte {
+	m := hmac.New(sha256.New, []byte(key))
+	m.Write(msg)
+	return m.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyGetAPIKeyBySignature(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{Name: "edge", Scopes: []string{ScopeIngest}}
+	token, err := k.CreateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := token[len(k.ID)+1:]
+
+	// test signed with signing key of token
+	key, err := SigningKey(token)
+	if err != nil {
+		t.Fatalf("data.SigningKey() returned error: %v", err)
+	}
+	msg := []byte("POST\n/v1/heartbeats\n1638139791\n0123456789abcdef\ne3b0c44298fc1c149afbf4c8996fb924")
+	sig := signMessage(key, msg)
+	got := NewAPIKey()
+	if err := got.GetAPIKeyBySignature(k.ID, msg, hex.EncodeToString(sig)); err != nil || got.ID != k.ID || got.Hash != "" {
+		t.Fatalf("data.GetAPIKeyBySignature() returned: %v, %v, wanted: %v", got, err, k)
+	}
+
+	// test denied signatures
+	for _, v := range []struct{ id, sig string }{
+		{k.ID, hex.EncodeToString(signMessage(hashSecret(secret), msg))}, // stored hash
+		{k.ID, hex.EncodeToString(signMessage("wrong", msg))},
+		{k.ID, "not hex"},
+		{"unknown", hex.EncodeToString(sig)},
+	} {
+		if err := NewAPIKey().GetAPIKeyBySignature(v.id, msg, v.sig); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.GetAPIKeyBySignature(%q) returned: %v, wanted: %v", v.id, err, ErrPermissionDenied)
+		}
+	}
+
+	// test rotated signing key accepted during grace
+	if _, err := NewAPIKey().RotateAPIKey(k.ID, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAPIKey().GetAPIKeyBySignature(k.ID, msg, hex.EncodeToString(sig)); err != nil {
+		t.Fatalf("data.GetAPIKeyBySignature() returned error: %v", err)
+	}
+}
+
+func TestAPIKeyUseNonce(t *testing.T) {
+	setTestDB(t)
+
+	k := &APIKey{ID: "0a1b2c3d"}
+	now := time.Now()
+	if err := k.UseNonce("0123456789abcdef", now, time.Minute); err != nil {
+		t.Fatalf("data.UseNonce() returned error: %v", err)
+	}
+
+	// test replayed nonce
+	if err := k.UseNonce("0123456789abcdef", now, time.Minute); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.UseNonce() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+
+	// test nonce of other key
+	if err := (&APIKey{ID: "4e5f6a7b"}).UseNonce("0123456789abcdef", now, time.Minute); err != nil {
+		t.Fatalf("data.UseNonce() returned error: %v", err)
+	}
+
+	// test expired nonces deleted
+	old := now.Add(-time.Hour)
+	if err := k.UseNonce("fedcba9876543210", old, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.UseNonce("fedcba9876543210", old, time.Minute); err != nil {
+		t.Fatalf("data.UseNonce() returned: %v, wanted expired nonce deleted", err)
+	}
+}
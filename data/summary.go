@@ -0,0 +1,119 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	summaryDayLayout = "2006-01-02"
+)
+
+type UptimeSummary struct {
+	Addr            string       `json:"address"`
+	ExpectedBlocks  int          `json:"expected_blocks"`
+	BroadcastBlocks int          `json:"broadcast_blocks"`
+	MissedBlocks    int          `json:"missed_blocks"`
+	Uptime          float64      `json:"uptime"`
+	FirstBroadcast  *time.Time   `json:"first_broadcast"`
+	LastBroadcast   *time.Time   `json:"last_broadcast"`
+	Days            []*UptimeDay `json:"days,omitempty"`
+}
+
+type UptimeDay struct {
+	Date            string  `json:"date"`
+	ExpectedBlocks  int     `json:"expected_blocks"`
+	BroadcastBlocks int     `json:"broadcast_blocks"`
+	MissedBlocks    int     `json:"missed_blocks"`
+	Uptime          float64 `json:"uptime"`
+}
+
+func NewUptimeSummary() *UptimeSummary {
+	return &UptimeSummary{}
+}
+
+func (us *UptimeSummary) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(us)
+}
+
+func (us *UptimeSummary) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(us)
+}
+
+func (us *UptimeSummary) GetUptimeSummaryByAddrByRange(addr, min, max string, daily bool) error {
+	// get blocks by range // consider running in goroutine with channel
+	vkl := NewBlocks()
+	if err := vkl.GetBlocksByRange(min, max, nil); err != nil {
+		return err
+	}
+
+	// get broadcasts by range // consider running in goroutine with channel
+	uml := NewUMBroadcasts()
+	if err := uml.GetUMBroadcastsByAddrByRange(addr, min, max, nil); err != nil {
+		return err
+	}
+
+	// compute summary
+	us.Addr = addr
+	us.summarize(vkl, uml, daily)
+
+	return nil
+}
+
+func (us *UptimeSummary) summarize(vkl *Blocks, uml *UMBroadcasts, daily bool) {
+	// create map of broadcasts & find first and last
+	m := make(map[int]bool)
+	for _, v := range *uml {
+		m[v.Height] = true
+
+		t := v.CreatedAt
+		if us.FirstBroadcast == nil || t.Before(*us.FirstBroadcast) {
+			us.FirstBroadcast = &t
+		}
+		if us.LastBroadcast == nil || t.After(*us.LastBroadcast) {
+			us.LastBroadcast = &t
+		}
+	}
+
+	// count expected & broadcast blocks, blocks are in descending order
+	var day *UptimeDay
+	for _, v := range *vkl {
+		us.ExpectedBlocks++
+		if m[v.Height] {
+			us.BroadcastBlocks++
+		}
+
+		if !daily {
+			continue
+		}
+
+		d := v.CreatedAt.UTC().Format(summaryDayLayout)
+		if day == nil || day.Date != d {
+			day = &UptimeDay{Date: d}
+			us.Days = append(us.Days, day)
+		}
+		day.ExpectedBlocks++
+		if m[v.Height] {
+			day.BroadcastBlocks++
+		}
+	}
+
+	us.MissedBlocks = us.ExpectedBlocks - us.BroadcastBlocks
+	us.Uptime = uptimePercent(us.BroadcastBlocks, us.ExpectedBlocks)
+
+	for _, v := range us.Days {
+		v.MissedBlocks = v.ExpectedBlocks - v.BroadcastBlocks
+		v.Uptime = uptimePercent(v.BroadcastBlocks, v.ExpectedBlocks)
+	}
+}
+
+func uptimePercent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	// round to two decimal places
+	return math.Round(float64(n)/float64(total)*10000) / 100
+}
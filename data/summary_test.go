@@ -0,0 +1,80 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewUptimeSummary(t *testing.T) {
+	want := &UptimeSummary{}
+	got := NewUptimeSummary()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewUptimeSummary() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestUptimeSummaryGetUptimeSummaryByAddrByRange(t *testing.T) {}
+
+func TestUptimeSummarySummarize(t *testing.T) {
+	vt0, _ := time.Parse(time.RFC3339, "2021-11-28T22:49:51Z")
+	vt1, _ := time.Parse(time.RFC3339, "2021-11-28T23:00:26Z")
+	vt2, _ := time.Parse(time.RFC3339, "2021-11-29T00:10:12Z")
+
+	testUptimes := &UMBroadcasts{
+		{Height: 13040301, Addr: "0x1a2b3c", CreatedAt: vt2},
+		{Height: 13040101, Addr: "0x1a2b3c", CreatedAt: vt0},
+	}
+	testBlocks := &Blocks{
+		{Height: 13040401, CreatedAt: vt2.Add(time.Minute * 10)},
+		{Height: 13040301, CreatedAt: vt2},
+		{Height: 13040201, CreatedAt: vt1},
+		{Height: 13040101, CreatedAt: vt0},
+	}
+
+	want := &UptimeSummary{
+		ExpectedBlocks:  4,
+		BroadcastBlocks: 2,
+		MissedBlocks:    2,
+		Uptime:          50,
+		FirstBroadcast:  &vt0,
+		LastBroadcast:   &vt2,
+		Days: []*UptimeDay{
+			{Date: "2021-11-29", ExpectedBlocks: 2, BroadcastBlocks: 1, MissedBlocks: 1, Uptime: 50},
+			{Date: "2021-11-28", ExpectedBlocks: 2, BroadcastBlocks: 1, MissedBlocks: 1, Uptime: 50},
+		},
+	}
+	got := NewUptimeSummary()
+	got.summarize(testBlocks, testUptimes, true)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.UptimeSummarySummarize() returned: %+v, wanted: %+v", got, want)
+	}
+
+	// test without days
+	got = NewUptimeSummary()
+	got.summarize(testBlocks, testUptimes, false)
+
+	if got.Days != nil {
+		t.Fatalf("data.UptimeSummarySummarize() returned days: %v, wanted: %v", got.Days, nil)
+	}
+
+	// test no blocks
+	got = NewUptimeSummary()
+	got.summarize(NewBlocks(), NewUMBroadcasts(), false)
+
+	if got.Uptime != 0 || got.FirstBroadcast != nil {
+		t.Fatalf("data.UptimeSummarySummarize() returned: %+v, wanted empty summary", got)
+	}
+}
+
+func TestUptimePercent(t *testing.T) {
+	if got := uptimePercent(2, 3); got != 66.67 {
+		t.Fatalf("data.uptimePercent() returned: %v, wanted: %v", got, 66.67)
+	}
+
+	if got := uptimePercent(0, 0); got != 0 {
+		t.Fatalf("data.uptimePercent() returned: %v, wanted: %v", got, 0)
+	}
+}
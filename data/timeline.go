@@ -0,0 +1,207 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	EventBroadcast              = "broadcast"
+	EventPeersChanged           = "peers_changed"
+	EventMissedBlock            = "missed_block"
+	EventOutageStart            = "outage_start"
+	EventOutageEnd              = "outage_end"
+	EventInsufficientPeersStart = "insufficient_peers_start"
+	EventInsufficientPeersEnd   = "insufficient_peers_end"
+)
+
+// eventRanks orders events sharing the same time
+var eventRanks = map[string]int{
+	EventOutageEnd:              0,
+	EventInsufficientPeersEnd:   1,
+	EventBroadcast:              2,
+	EventPeersChanged:           3,
+	EventOutageStart:            4,
+	EventMissedBlock:            5,
+	EventInsufficientPeersStart: 6,
+}
+
+type TimelineEvent struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Addr string      `json:"address"`
+	Data interface{} `json:"data"`
+}
+
+type Outage struct {
+	StartHeight  int `json:"start_height"`
+	EndHeight    int `json:"end_height,omitempty"`
+	MissedBlocks int `json:"missed_blocks"`
+}
+
+func NewTimelineEvent() *TimelineEvent {
+	return &TimelineEvent{}
+}
+
+func (ev *TimelineEvent) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(ev)
+}
+
+// timelineEventCSVHeader orders csv columns as json fields
+var timelineEventCSVHeader = []string{"type", "time", "address", "data"}
+
+func (ev *TimelineEvent) csvRecord() []string {
+	// encode event data as json cell
+	b, _ := json.Marshal(ev.Data)
+
+	return []string{
+		ev.Type,
+		csvTime(ev.Time),
+		ev.Addr,
+		string(b),
+	}
+}
+
+type Timeline []*TimelineEvent
+
+func NewTimeline() *Timeline {
+	return &Timeline{}
+}
+
+func (tl *Timeline) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(tl)
+}
+
+func (tl *Timeline) ToCSV(w io.Writer, fields ...string) error {
+	l := *tl
+	return writeCSV(w, timelineEventCSVHeader, fields, len(l), func(i int) []string { return l[i].csvRecord() })
+}
+
+func (tl *Timeline) ToNDJSON(w io.Writer) error {
+	l := *tl
+	return writeNDJSON(w, len(l), func(i int) interface{} { return l[i] })
+}
+
+func (tl *Timeline) GetTimelineByAddrByRange(addr, min, max string, pg *Page) error {
+	// get blocks by range // consider running in goroutine with channel
+	vkl := NewBlocks()
+	if err := vkl.GetBlocksByRange(min, max, nil); err != nil {
+		return err
+	}
+
+	// get broadcasts by range // consider running in goroutine with channel
+	uml := NewUMBroadcasts()
+	if err := uml.GetUMBroadcastsByAddrByRange(addr, min, max, nil); err != nil {
+		return err
+	}
+
+	// get peers by range // consider running in goroutine with channel
+	p2pl := NewP2Ps()
+	if err := p2pl.GetNumPeersByAddrByRange(addr, min, max, nil); err != nil {
+		return err
+	}
+
+	// merge events
+	tl.addBroadcasts(uml)
+	tl.addPeers(p2pl)
+	tl.addMissedBlocks(addr, vkl, uml)
+
+	pil := NewPeerIncidents()
+	pil.findPeerIncidents(p2pl)
+	tl.addPeerIncidents(pil)
+
+	tl.sort(pg.ascending(false))
+
+	// trim to page
+	lo, hi := pg.window(len(*tl))
+	*tl = (*tl)[lo:hi]
+
+	return nil
+}
+
+func (tl *Timeline) add(typ string, t time.Time, addr string, v interface{}) {
+	*tl = append(*tl, &TimelineEvent{Type: typ, Time: t, Addr: addr, Data: v})
+}
+
+func (tl *Timeline) addBroadcasts(uml *UMBroadcasts) {
+	for _, v := range *uml {
+		tl.add(EventBroadcast, v.CreatedAt, v.Addr, v)
+	}
+}
+
+func (tl *Timeline) addPeers(p2pl *P2Ps) {
+	// scan in ascending order, peers are in descending order
+	var prev *P2P
+	for i := len(*p2pl) - 1; i >= 0; i-- {
+		v := (*p2pl)[i]
+		if prev == nil || prev.NumPeers != v.NumPeers || prev.SufficientPeers != v.SufficientPeers {
+			tl.add(EventPeersChanged, v.CreatedAt, v.Addr, v)
+		}
+		prev = v
+	}
+}
+
+func (tl *Timeline) addMissedBlocks(addr string, vkl *Blocks, uml *UMBroadcasts) {
+	// create map of broadcasts
+	m := make(map[int]bool)
+	for _, v := range *uml {
+		m[v.Height] = true
+	}
+
+	// scan in ascending order, blocks are in descending order
+	var ot *Outage
+	for i := len(*vkl) - 1; i >= 0; i-- {
+		v := (*vkl)[i]
+
+		// close outage on first broadcast block
+		if m[v.Height] {
+			if ot != nil {
+				ot.EndHeight = v.Height
+				tl.add(EventOutageEnd, v.CreatedAt, addr, ot)
+				ot = nil
+			}
+			continue
+		}
+
+		// open outage on first missed block
+		if ot == nil {
+			ot = &Outage{StartHeight: v.Height}
+			tl.add(EventOutageStart, v.CreatedAt, addr, ot)
+		}
+		ot.MissedBlocks++
+
+		tl.add(EventMissedBlock, v.CreatedAt, addr, v)
+	}
+}
+
+func (tl *Timeline) addPeerIncidents(pil *PeerIncidents) {
+	for _, v := range *pil {
+		tl.add(EventInsufficientPeersStart, v.Start, v.Addr, v)
+		if !v.Ongoing {
+			tl.add(EventInsufficientPeersEnd, v.End, v.Addr, v)
+		}
+	}
+}
+
+func (tl *Timeline) sort(asc bool) {
+	l := *tl
+
+	// sort in ascending order
+	sort.SliceStable(l, func(i, j int) bool {
+		if !l[i].Time.Equal(l[j].Time) {
+			return l[i].Time.Before(l[j].Time)
+		}
+		return eventRanks[l[i].Type] < eventRanks[l[j].Type]
+	})
+
+	if asc {
+		return
+	}
+
+	// reverse to descending order
+	for i, j := 0, len(l)-1; i < j; i, j = i+1, j-1 {
+		l[i], l[j] = l[j], l[i]
+	}
+}
@@ -0,0 +1,89 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewTimeline(t *testing.T) {
+	want := &Timeline{}
+	got := NewTimeline()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewTimeline() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestTimelineGetTimelineByAddrByRange(t *testing.T) {}
+
+func testTimeline() *Timeline {
+	vt, _ := time.Parse(time.RFC3339, "2021-11-28T22:00:00Z")
+	at := func(m int) time.Time { return vt.Add(time.Minute * time.Duration(m)) }
+
+	// records in descending order
+	vkl := &Blocks{
+		{Height: 13040401, CreatedAt: at(40)},
+		{Height: 13040301, CreatedAt: at(30)},
+		{Height: 13040201, CreatedAt: at(20)},
+		{Height: 13040101, CreatedAt: at(10)},
+	}
+	uml := &UMBroadcasts{
+		{Addr: "0x1a2b3c", Height: 13040401, CreatedAt: at(40)},
+		{Addr: "0x1a2b3c", Height: 13040101, CreatedAt: at(10)},
+	}
+	p2pl := &P2Ps{
+		{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16, CreatedAt: at(35)},
+		{Addr: "0x1a2b3c", NumPeers: 4, SufficientPeers: 16, CreatedAt: at(25)},
+		{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16, CreatedAt: at(15)},
+		{Addr: "0x1a2b3c", NumPeers: 16, SufficientPeers: 16, CreatedAt: at(5)},
+	}
+
+	tl := NewTimeline()
+	tl.addBroadcasts(uml)
+	tl.addPeers(p2pl)
+	tl.addMissedBlocks("0x1a2b3c", vkl, uml)
+
+	pil := NewPeerIncidents()
+	pil.findPeerIncidents(p2pl)
+	tl.addPeerIncidents(pil)
+
+	return tl
+}
+
+func TestTimelineSort(t *testing.T) {
+	want := []string{
+		EventPeersChanged,           // 5
+		EventBroadcast,              // 10
+		EventOutageStart,            // 20
+		EventMissedBlock,            // 20
+		EventPeersChanged,           // 25
+		EventInsufficientPeersStart, // 25
+		EventMissedBlock,            // 30
+		EventInsufficientPeersEnd,   // 35
+		EventPeersChanged,           // 35
+		EventOutageEnd,              // 40
+		EventBroadcast,              // 40
+	}
+
+	// test ascending order
+	tl := testTimeline()
+	tl.sort(true)
+
+	var got []string
+	for _, v := range *tl {
+		got = append(got, v.Type)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.TimelineSort() returned: %v, wanted: %v", got, want)
+	}
+
+	// test descending order
+	tl = testTimeline()
+	tl.sort(false)
+
+	if v := (*tl)[0]; v.Type != EventBroadcast || v.Time.Before((*tl)[len(*tl)-1].Time) {
+		t.Fatalf("data.TimelineSort() returned first: %v, wanted newest %v", v.Type, EventBroadcast)
+	}
+}
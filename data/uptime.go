@@ -29,7 +29,28 @@ func (um *UMBroadcast) ToJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(um)
 }
 
+// umBroadcastCSVHeader orders csv columns as json fields
+var umBroadcastCSVHeader = []string{"block", "height", "address", "signature", "timestamp", "num_peers", "sufficient_peers", "created_at"}
+
+func (um *UMBroadcast) csvRecord() []string {
+	return []string{
+		um.Block,
+		csvInt(um.Height),
+		um.Addr,
+		um.Signature,
+		csvInt(um.Timestamp),
+		csvInt(um.NumPeers),
+		csvInt(um.SufficientPeers),
+		csvTime(um.CreatedAt),
+	}
+}
+
 func (um *UMBroadcast) CreateUMBroadcast() error {
+	// validate address
+	if um.Addr == "" {
+		return Errorf(ErrInvalidArgument, "error missing address")
+	}
+
 	// write to stats current table
 	if err := um.updateUMBroadcasts(); err != nil {
 		return err
@@ -55,7 +76,7 @@ func (um *UMBroadcast) updateUMBroadcasts() error {
 	k := um.Addr
 	v, err := json.Marshal(um)
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write data to db
@@ -71,7 +92,7 @@ func (um *UMBroadcast) createUMBroadcastsByAddr() error {
 	k := um.CreatedAt.Format(time.RFC3339)
 	v, err := json.Marshal(um)
 	if err != nil {
-		return err
+		return wrapError(ErrInternal, err)
 	}
 
 	// write data to db
@@ -96,9 +117,32 @@ func (um *UMBroadcasts) ToJSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(um)
 }
 
-func (uml *UMBroadcasts) GetUMBroadcasts() error {
+func (um *UMBroadcasts) ToCSV(w io.Writer, fields ...string) error {
+	l := *um
+	return writeCSV(w, umBroadcastCSVHeader, fields, len(l), func(i int) []string { return l[i].csvRecord() })
+}
+
+func (um *UMBroadcasts) csvHeader() []string {
+	return umBroadcastCSVHeader
+}
+
+func (um *UMBroadcasts) csvDecode(b []byte) ([]string, error) {
+	v := NewUMBroadcast()
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, wrapError(ErrInternal, err)
+	}
+
+	return v.csvRecord(), nil
+}
+
+func (um *UMBroadcasts) ToNDJSON(w io.Writer) error {
+	l := *um
+	return writeNDJSON(w, len(l), func(i int) interface{} { return l[i] })
+}
+
+func (uml *UMBroadcasts) GetUMBroadcasts(pg *Page) error {
 	// read data from db
-	buf, err := scanData([]byte(statsUptimesBroadcasts))
+	buf, err := scanData([]byte(statsUptimesBroadcasts), pg)
 	if err != nil {
 		return err
 	}
@@ -107,9 +151,9 @@ func (uml *UMBroadcasts) GetUMBroadcasts() error {
 	return uml.unmarshalData(buf)
 }
 
-func (uml *UMBroadcasts) GetUMBroadcastsByAddr(addr string) error {
+func (uml *UMBroadcasts) GetUMBroadcastsByAddr(addr string, pg *Page) error {
 	// read data from db
-	buf, err := scanNestedData([]byte(statsUptimesBroadcatsByAddr), []byte(addr))
+	buf, err := scanNestedData([]byte(statsUptimesBroadcatsByAddr), []byte(addr), pg)
 	if err != nil {
 		return err
 	}
@@ -118,14 +162,15 @@ func (uml *UMBroadcasts) GetUMBroadcastsByAddr(addr string) error {
 	return uml.unmarshalData(buf)
 }
 
-func (uml *UMBroadcasts) GetUMBroadcastsByAddrByRange(addr, min, max string) error {
+func (uml *UMBroadcasts) GetUMBroadcastsByAddrByRange(addr, min, max string, pg *Page) error {
 	// validate times
-	if err := validateTimes(min, max); err != nil {
+	min, max, err := normalizeTimes(min, max)
+	if err != nil {
 		return err
 	}
 
 	// read data from db
-	buf, err := scanNestedDataByRange([]byte(statsUptimesBroadcatsByAddr), []byte(addr), []byte(min), []byte(max))
+	buf, err := scanNestedDataByRange([]byte(statsUptimesBroadcatsByAddr), []byte(addr), []byte(min), []byte(max), pg)
 	if err != nil {
 		return err
 	}
@@ -134,9 +179,30 @@ func (uml *UMBroadcasts) GetUMBroadcastsByAddrByRange(addr, min, max string) err
 	return uml.unmarshalData(buf)
 }
 
-func (uml *UMBroadcasts) GetUMBroadcastsByCluster(addrs string) error {
+func (uml *UMBroadcasts) StreamUMBroadcasts(pg *Page, sw StreamWriter) error {
+	// stream data from db
+	return streamData([]byte(statsUptimesBroadcasts), nil, nil, nil, pg, sw)
+}
+
+func (uml *UMBroadcasts) StreamUMBroadcastsByAddr(addr string, pg *Page, sw StreamWriter) error {
+	// stream data from db
+	return streamData([]byte(statsUptimesBroadcatsByAddr), []byte(addr), nil, nil, pg, sw)
+}
+
+func (uml *UMBroadcasts) StreamUMBroadcastsByAddrByRange(addr, min, max string, pg *Page, sw StreamWriter) error {
+	// validate times
+	min, max, err := normalizeTimes(min, max)
+	if err != nil {
+		return err
+	}
+
+	// stream data from db
+	return streamData([]byte(statsUptimesBroadcatsByAddr), []byte(addr), []byte(min), rangeMax(max), pg, sw)
+}
+
+func (uml *UMBroadcasts) GetUMBroadcastsByCluster(addrs string, pg *Page) error {
 	// split addrs string
-	addrl := splitAddrs(addrs)
+	addrl := pageAddrs(splitAddrs(addrs), pg)
 
 	// read data from db
 	var buf [][]byte
@@ -145,6 +211,9 @@ func (uml *UMBroadcasts) GetUMBroadcastsByCluster(addrs string) error {
 		if err != nil {
 			return err // consider skip return and read as many as possible
 		}
+		if b == nil {
+			return Errorf(ErrNotFound, "error no data for address: %s", addr)
+		}
 
 		buf = append(buf, b)
 	}
@@ -157,7 +226,7 @@ func (uml *UMBroadcasts) unmarshalData(buf [][]byte) error {
 	for _, b := range buf {
 		um := NewUMBroadcast()
 		if err := json.Unmarshal(b, um); err != nil {
-			return err
+			return wrapError(ErrInternal, err)
 		}
 
 		*uml = append(*uml, um)
@@ -0,0 +1,425 @@
+package data
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// roleScopes are the api key scopes of each role
+var roleScopes = map[string][]string{
+	RoleViewer:   {ScopeRead},
+	RoleOperator: {ScopeRead, ScopeIngest},
+	RoleAdmin:    {ScopeAdmin},
+}
+
+// UserKeyName names the keys of requests with a session token
+const UserKeyName = "user"
+
+var usernameRe = regexp.MustCompile(`^[a-z0-9._-]{1,64}$`)
+
+// dummyHash is compared for unknown users, so logins take alike long
+var dummyHash = []byte("$2a$10$Z33UDOYiGKM80lnaJqQ56OPOER3lZFBVsNTDJGqUNi9fVvc7cwJDq")
+
+// User is stored by username, its password is only kept as bcrypt hash
+type User struct {
+	Username    string     `json:"username"`
+	Role        string     `json:"role"`
+	Addresses   []string   `json:"addresses,omitempty"` // address patterns, empty allows all
+	Password    string     `json:"password,omitempty"`  // only read on create
+	Hash        string     `json:"hash,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func NewUser() *User {
+	return &User{}
+}
+
+func (u *User) FromJSON(r io.Reader) error {
+	return json.NewDecoder(r).Decode(u)
+}
+
+func (u *User) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(u)
+}
+
+// CreateUser stores the username, role, addresses & password hash of u
+func (u *User) CreateUser() error {
+	// validate user
+	if !usernameRe.MatchString(u.Username) {
+		return Errorf(ErrInvalidArgument, "error with username: %s", u.Username)
+	}
+	if _, ok := roleScopes[u.Role]; !ok {
+		return Errorf(ErrInvalidArgument, "error with role: %s", u.Role)
+	}
+	if err := checkAddrPatterns(u.Addresses); err != nil {
+		return err
+	}
+	if len(u.Password) < 8 || len(u.Password) > 72 {
+		return Errorf(ErrInvalidArgument, "error password must have 8 to 72 bytes")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	*u = User{
+		Username:  u.Username,
+		Role:      u.Role,
+		Addresses: u.Addresses,
+		Hash:      string(hash),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	// set key & value
+	v, err := json.Marshal(u)
+	if err != nil {
+		return wrapError(ErrInternal, err)
+	}
+
+	// write data to db unless the user exists
+	ok, err := insertData([]byte(authUsers), []byte(u.Username), v, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return Errorf(ErrInvalidArgument, "error user exists: %s", u.Username)
+	}
+
+	u.Hash = ""
+	return nil
+}
+
+// GetUser reads a user by username without its hash
+func (u *User) GetUser(username string) error {
+	if err := u.getUser(username); err != nil {
+		return err
+	}
+
+	u.Hash = ""
+	return nil
+}
+
+func (u *User) getUser(username string) error {
+	// read data from db
+	b, err := readData([]byte(authUsers), []byte(username))
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return Errorf(ErrNotFound, "error no user for username: %s", username)
+	}
+
+	// unmarshal data to struct
+	return wrapError(ErrInternal, json.Unmarshal(b, u))
+}
+
+// DisableUser denies all further logins & sessions of username
+func (u *User) DisableUser(username string) error {
+	err := u.modifyUser(username, func(u *User) {
+		if u.DisabledAt == nil {
+			now := time.Now().UTC()
+			u.DisabledAt = &now
+		}
+	})
+
+	u.Hash = ""
+	return err
+}
+
+func (u *User) modifyUser(username string, fn func(u *User)) error {
+	return updateData([]byte(authUsers), []byte(username), func(v []byte) ([]byte, error) {
+		if v == nil {
+			return nil, Errorf(ErrNotFound, "error no user for username: %s", username)
+		}
+
+		// unmarshal data to struct
+		*u = User{}
+		if err := json.Unmarshal(v, u); err != nil {
+			return nil, err
+		}
+
+		fn(u)
+		return json.Marshal(u)
+	})
+}
+
+// APIKey is the key of requests with a session of the user, its scopes are
+// those of the user role and its addresses those of the user
+func (u *User) APIKey() *APIKey {
+	return &APIKey{ID: UserKeyName + ":" + u.Username, Name: UserKeyName, Scopes: roleScopes[u.Role], Addresses: u.Addresses}
+}
+
+type Users []*User
+
+func NewUsers() *Users {
+	return &Users{}
+}
+
+func (ul *Users) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(ul)
+}
+
+func (ul *Users) GetUsers() error {
+	// read data from db
+	buf, err := scanData([]byte(authUsers), &Page{Order: OrderAsc})
+	if err != nil {
+		return err
+	}
+
+	// unmarshal data to struct
+	for _, b := range buf {
+		u := NewUser()
+		if err := json.Unmarshal(b, u); err != nil {
+			return wrapError(ErrInternal, err)
+		}
+
+		u.Hash = ""
+		*ul = append(*ul, u)
+	}
+
+	return nil
+}
+
+// Session is a login of a user until it expires or logs out, its token
+// carries the signed session so only logout & the user are read from db
+type Session struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	Role      string     `json:"role"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// sessionClaims are signed into a session token
+type sessionClaims struct {
+	ID        string `json:"id"`
+	Username  string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func NewSession() *Session {
+	return &Session{}
+}
+
+func (s *Session) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// SessionToken is a session with its token, returned once on login
+type SessionToken struct {
+	Session
+	Token string `json:"token"`
+}
+
+func (st *SessionToken) ToJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(st)
+}
+
+// Login stores a session of ttl if password matches the user and returns its
+// token, unknown & disabled users are denied alike
+func (s *Session) Login(username, password string, ttl time.Duration) (string, error) {
+	denied := Errorf(ErrPermissionDenied, "error wrong username or password")
+
+	// compare password hashes
+	u := NewUser()
+	err := u.getUser(username)
+	if errors.Is(err, ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return "", denied
+	} else if err != nil {
+		return "", err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Hash), []byte(password)) != nil || u.DisabledAt != nil {
+		return "", denied
+	}
+
+	id, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	*s = Session{
+		ID:        id,
+		Username:  u.Username,
+		Role:      u.Role,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+		CreatedAt: now,
+	}
+
+	// sign claims
+	token, err := s.sign()
+	if err != nil {
+		return "", err
+	}
+
+	// set key & value
+	v, err := json.Marshal(s)
+	if err != nil {
+		return "", wrapError(ErrInternal, err)
+	}
+
+	// write data to db, expired sessions are deleted
+	if _, err := insertData([]byte(authSessions), sessionKey(s.ID, s.ExpiresAt.Unix()), v, []byte(fmt.Sprintf("%020d", now.Unix()))); err != nil {
+		return "", err
+	}
+
+	// record login of user
+	if err := NewUser().modifyUser(u.Username, func(u *User) { u.LastLoginAt = &now }); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetSessionByToken reads the session of a token and the user it belongs to,
+// forged, expired & logged out tokens and disabled users are all denied alike
+func (s *Session) GetSessionByToken(token string) (*User, error) {
+	denied := Errorf(ErrPermissionDenied, "error not authorized")
+
+	c, err := verifySession(token)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || time.Now().Unix() >= c.ExpiresAt {
+		return nil, denied
+	}
+
+	// read logout from db
+	b, err := readData([]byte(authSessions), sessionKey(c.ID, c.ExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, denied
+	}
+	stored := NewSession()
+	if err := json.Unmarshal(b, stored); err != nil {
+		return nil, wrapError(ErrInternal, err)
+	}
+	if stored.RevokedAt != nil {
+		return nil, denied
+	}
+
+	// read user from db, its role may have changed since login
+	u := NewUser()
+	if err := u.getUser(stored.Username); errors.Is(err, ErrNotFound) {
+		return nil, denied
+	} else if err != nil {
+		return nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, denied
+	}
+	u.Hash = ""
+
+	*s = *stored
+	s.Role = u.Role
+	return u, nil
+}
+
+// Logout denies all further requests with the session of token
+func (s *Session) Logout(token string) error {
+	c, err := verifySession(token)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return Errorf(ErrPermissionDenied, "error not authorized")
+	}
+
+	err = updateData([]byte(authSessions), sessionKey(c.ID, c.ExpiresAt), func(v []byte) ([]byte, error) {
+		if v == nil {
+			return nil, Errorf(ErrNotFound, "error no session for id: %s", c.ID)
+		}
+
+		// unmarshal data to struct
+		*s = Session{}
+		if err := json.Unmarshal(v, s); err != nil {
+			return nil, err
+		}
+
+		if s.RevokedAt == nil {
+			now := time.Now().UTC()
+			s.RevokedAt = &now
+		}
+
+		return json.Marshal(s)
+	})
+
+	return err
+}
+
+func (s *Session) sign() (string, error) {
+	secret, err := authSecret("session_secret")
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(&sessionClaims{s.ID, s.Username, s.ExpiresAt.Unix()})
+	if err != nil {
+		return "", wrapError(ErrInternal, err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signMessage(string(secret), []byte(payload))), nil
+}
+
+// verifySession returns the claims of a token, nil if forged
+func verifySession(token string) (*sessionClaims, error) {
+	// split token
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return nil, nil
+	}
+	payload, sig := token[:i], token[i+1:]
+
+	// compare signatures in constant time
+	secret, err := authSecret("session_secret")
+	if err != nil {
+		return nil, err
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signMessage(string(secret), []byte(payload))) {
+		return nil, nil
+	}
+
+	// unmarshal claims
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, nil
+	}
+	c := &sessionClaims{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, nil
+	}
+
+	return c, nil
+}
+
+// sessionKey orders sessions by expiry, so expired ones are deleted in order
+func sessionKey(id string, exp int64) []byte {
+	return []byte(fmt.Sprintf("%020d/%s", exp, id))
+}
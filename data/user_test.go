@@ -0,0 +1,115 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewUser(t *testing.T) {
+	want := &User{}
+	got := NewUser()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("data.NewUser() returned: %v, wanted: %v", got, want)
+	}
+}
+
+func TestUserCreateUser(t *testing.T) {
+	setTestDB(t)
+
+	// test invalid users
+	for _, u := range []*User{
+		{Username: "Alice", Role: RoleViewer, Password: "password1"},
+		{Username: "alice", Role: "owner", Password: "password1"},
+		{Username: "alice", Role: RoleViewer, Password: "short"},
+		{Username: "alice", Role: RoleViewer, Addresses: []string{"0x[abc"}, Password: "password1"},
+	} {
+		if err := u.CreateUser(); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("data.CreateUser(%v) returned: %v, wanted: %v", u, err, ErrInvalidArgument)
+		}
+	}
+
+	// test created user without password & hash
+	u := &User{Username: "alice", Role: RoleViewer, Password: "password1"}
+	if err := u.CreateUser(); err != nil || u.Password != "" || u.Hash != "" {
+		t.Fatalf("data.CreateUser() returned: %v, %v", u, err)
+	}
+	got := NewUser()
+	if err := got.GetUser("alice"); err != nil || got.Role != RoleViewer || got.Hash != "" {
+		t.Fatalf("data.GetUser() returned: %v, %v", got, err)
+	}
+
+	// test existing user
+	u = &User{Username: "alice", Role: RoleAdmin, Password: "password2"}
+	if err := u.CreateUser(); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("data.CreateUser() returned: %v, wanted: %v", err, ErrInvalidArgument)
+	}
+}
+
+func TestSessionLogin(t *testing.T) {
+	setTestDB(t)
+
+	u := &User{Username: "bob", Role: RoleOperator, Addresses: []string{"0xabc*"}, Password: "password1"}
+	if err := u.CreateUser(); err != nil {
+		t.Fatal(err)
+	}
+
+	// test wrong credentials
+	for _, v := range [][2]string{{"bob", "password2"}, {"carol", "password1"}} {
+		if _, err := NewSession().Login(v[0], v[1], time.Hour); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.Login(%q) returned: %v, wanted: %v", v[0], err, ErrPermissionDenied)
+		}
+	}
+
+	// test session by token
+	s := NewSession()
+	token, err := s.Login("bob", "password1", time.Hour)
+	if err != nil {
+		t.Fatalf("data.Login() returned error: %v", err)
+	}
+	got := NewSession()
+	gu, err := got.GetSessionByToken(token)
+	if err != nil || got.ID != s.ID || gu.Username != "bob" || gu.Hash != "" {
+		t.Fatalf("data.GetSessionByToken() returned: %v, %v, %v, wanted: %v", got, gu, err, s)
+	}
+	k := gu.APIKey()
+	if k.ID != "user:bob" || !k.HasScope(ScopeIngest) || !k.HasScope(ScopeRead) || k.HasScope(ScopeAdmin) || !k.AllowsAddr("0xABC1") || k.AllowsAddr("0xdef") {
+		t.Fatalf("data.APIKey() returned: %v", k)
+	}
+
+	// test forged & expired tokens
+	expired, err := NewSession().Login("bob", "password1", -time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"", "nodot", token + "x", expired} {
+		if _, err := NewSession().GetSessionByToken(v); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("data.GetSessionByToken(%q) returned: %v, wanted: %v", v, err, ErrPermissionDenied)
+		}
+	}
+
+	// test logout
+	if err := NewSession().Logout(token); err != nil {
+		t.Fatalf("data.Logout() returned error: %v", err)
+	}
+	if _, err := NewSession().GetSessionByToken(token); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.GetSessionByToken() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+
+	// test disabled user
+	token, err = NewSession().Login("bob", "password1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewUser().DisableUser("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSession().GetSessionByToken(token); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.GetSessionByToken() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+	if _, err := NewSession().Login("bob", "password1", time.Hour); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("data.Login() returned: %v, wanted: %v", err, ErrPermissionDenied)
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+// statusWriter keeps the response status of a handler
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// audited reports whether calls of a route are recorded, that is all admin
+// routes and all routes writing data, graphql posts only query
+func audited(rt *route) bool {
+	return rt.scope == data.ScopeAdmin || (rt.method != http.MethodGet && rt.path != graphqlPath)
+}
+
+// audit records the actor, target & outcome of each call of the route,
+// including calls denied by the checks of the api key, calls without valid
+// credentials are recorded by auditDenied
+func (h *Handler) audit(rt *route, next http.HandlerFunc) http.HandlerFunc {
+	if !audited(rt) {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// next handler
+		e := newAuditEntry(r, rt, mux.Vars(r))
+		sw := &statusWriter{ResponseWriter: w}
+		next(sw, r.WithContext(context.WithValue(r.Context(), ctxAudit, e)))
+
+		// update db collection
+		e.Actor = keyID(apiKeyFrom(r))
+		h.recordAudit(e, sw.status)
+	}
+}
+
+// auditRoute is the handler of an audited route in the audit router
+type auditRoute struct {
+	rt *route
+}
+
+// ServeHTTP is never called, the audit router only matches requests
+func (ar auditRoute) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+// newAuditRouter matches requests to audited routes before authentication
+func (h *Handler) newAuditRouter() *mux.Router {
+	ar := mux.NewRouter()
+	for _, rt := range h.routes() {
+		if !audited(rt) || rt.scope == scopePublic {
+			continue
+		}
+
+		ar.Handle(apiVersion+rt.path, auditRoute{rt}).Methods(rt.method)
+		for _, p := range rt.aliases {
+			ar.Handle(p, auditRoute{rt}).Methods(rt.method)
+		}
+	}
+
+	return ar
+}
+
+// auditDenied writes the response of a request denied before authentication
+// and records it to the audit log if its route is audited
+func (h *Handler) auditDenied(w http.ResponseWriter, r *http.Request, write func(w http.ResponseWriter)) {
+	var m mux.RouteMatch
+	var ar auditRoute
+	ok := h.ar.Match(r, &m)
+	if ok {
+		ar, ok = m.Handler.(auditRoute)
+	}
+	if !ok {
+		write(w)
+		return
+	}
+
+	sw := &statusWriter{ResponseWriter: w}
+	write(sw)
+
+	// update db collection
+	e := newAuditEntry(r, ar.rt, m.Vars)
+	e.Actor = keyID(nil)
+	h.recordAudit(e, sw.status)
+}
+
+// newAuditEntry sets the target of an entry to the path params, handlers may
+// set the target of the body
+func newAuditEntry(r *http.Request, rt *route, pp map[string]string) *data.AuditEntry {
+	e := &data.AuditEntry{Action: rt.name, Target: pp["addr"], IP: clientIP(r), RequestID: requestID(r)}
+	if e.Target == "" {
+		e.Target = pp["id"] + pp["name"]
+	}
+
+	return e
+}
+
+// recordAudit sets the outcome of the status of an entry and records it
+func (h *Handler) recordAudit(e *data.AuditEntry, status int) {
+	e.Status = status
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	switch {
+	case e.Status < http.StatusBadRequest:
+		e.Outcome = data.OutcomeSuccess
+	case e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden || e.Status == http.StatusTooManyRequests:
+		e.Outcome = data.OutcomeDenied
+	default:
+		e.Outcome = data.OutcomeFailure
+	}
+
+	// update db collection
+	if err := e.CreateAuditEntry(); err != nil {
+		h.l.Printf("Error recording audit entry: %s\n", err)
+	}
+}
+
+// setAuditTarget sets the target of the audit entry of a request, such as
+// the address of a request body
+func setAuditTarget(r *http.Request, target string) {
+	if e, ok := r.Context().Value(ctxAudit).(*data.AuditEntry); ok {
+		e.Target = target
+	}
+}
+
+func (h *Handler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	// get query params
+	qp := r.URL.Query()
+	f := &data.AuditFilter{Actor: qp.Get("actor"), Action: qp.Get("action"), Min: qp.Get("min"), Max: qp.Get("max")}
+
+	// get page params
+	limit, err := parseIntQuery(r, "limit", h.pl.limit)
+	if err != nil || limit < 1 {
+		h.writeError(w, r, invalidArgument("error with limit param"))
+		return
+	}
+	if limit > h.pl.maxLimit {
+		limit = h.pl.maxLimit
+	}
+	pg, err := data.NewPage(limit, qp.Get("cursor"), qp.Get("order"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// get data from db
+	al := data.NewAuditEntries()
+	if err := al.GetAuditEntries(f, pg); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	setLinkHeader(w, r, pg)
+
+	// encode to json byte array
+	if err := al.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/edgestats/edgestats-server/data"
 	"github.com/gorilla/mux"
@@ -11,7 +12,14 @@ func (h *Handler) CreateBlock(w http.ResponseWriter, r *http.Request) {
 	// get request body
 	bk := data.NewBlock()
 	if err := bk.FromJSON(r.Body); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+
+	// validate api key, blocks belong to no address
+	setAuditTarget(r, strconv.Itoa(bk.Height))
+	if err := checkAddr(r.Context(), ""); err != nil {
+		h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error blocks not allowed for api key"))
 		return
 	}
 
@@ -20,7 +28,7 @@ func (h *Handler) CreateBlock(w http.ResponseWriter, r *http.Request) {
 
 	// update db collection
 	if err := bk.CreateBlock(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -28,19 +36,35 @@ func (h *Handler) CreateBlock(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetBlocks(w http.ResponseWriter, r *http.Request) {
+	// get list params
+	q, err := h.parseListQuery(r, &data.Blocks{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewBlocks().StreamBlocks(q.pg, sw)
+		})
+		return
+	}
+
 	// get data from db
 	bk := data.NewBlocks()
-	if err := bk.GetBlocks(); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := bk.GetBlocks(q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := bk.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, bk, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -51,27 +75,43 @@ func (h *Handler) GetBlocksByRange(w http.ResponseWriter, r *http.Request) {
 
 	// validate params
 	if len(pp) < 1 || len(pp) > 2 { // if !(1 <= len(pp) <= 2)
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
-		http.Error(w, "error with times", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.Blocks{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewBlocks().StreamBlocksByRange(pp["min"], pp["max"], q.pg, sw)
+		})
 		return
 	}
 
 	// get data from db
 	bk := data.NewBlocks()
-	if err := bk.GetBlocksByRange(pp["min"], pp["max"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := bk.GetBlocksByRange(pp["min"], pp["max"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := bk.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, bk, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -82,31 +122,39 @@ func (h *Handler) GetMissedBlocksByAddrByRange(w http.ResponseWriter, r *http.Re
 
 	// validate params
 	if len(pp) < 2 || len(pp) > 3 { // if !(2 <= len(pp) <= 3)
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := isValidAddr(pp["addr"]); err != nil {
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
 		return
 	}
 	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
-		http.Error(w, "error with times", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.Blocks{})
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// get data from db
 	bk := data.NewBlocks()
-	if err := bk.GetMissedBlocksByAddrByRange(pp["addr"], pp["min"], pp["max"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := bk.GetMissedBlocksByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := bk.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, bk, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notModified sets the etag and last modified headers of a response written
+// at t and reports whether the client copy is current, a zero t sets none
+func notModified(w http.ResponseWriter, r *http.Request, t time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+
+	// tag write time per request uri, accepted format & api key, whose
+	// addresses may filter the data
+	var id string
+	if k := apiKeyFrom(r); k != nil {
+		id = k.ID
+	}
+	sum := sha1.Sum([]byte(strconv.FormatInt(t.UnixNano(), 10) + " " + r.URL.RequestURI() + " " + r.Header.Get("Accept") + " " + id))
+	etag := `W/"` + hex.EncodeToString(sum[:8]) + `"`
+
+	// set http response headers
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", apiKeyHdr)
+
+	// if-none-match takes precedence over if-modified-since
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag)
+	}
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !t.Truncate(time.Second).After(ims)
+	}
+
+	return false
+}
+
+// matchETag weakly compares the etags of an if-none-match header
+func matchETag(inm, etag string) bool {
+	for _, v := range strings.Split(inm, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
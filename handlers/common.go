@@ -2,12 +2,74 @@ package handlers
 
 import (
 	"log"
+	"net/http"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
 )
 
 type Handler struct {
-	l *log.Logger
+	l    *log.Logger
+	th   *data.HealthThresholds
+	pl   *pageLimits
+	api  *openAPI
+	cors func(http.Handler) http.Handler
+	gql  *graphql.Schema
+	gl   *graphqlLimits
+	kg   time.Duration // rotated key grace period
+	so   *signatureOptions
+	rl   *rateLimiter
+	sm   time.Duration   // max share age
+	sa   time.Duration   // max session age
+	pub  map[string]bool // paths of public routes
+	ar   *mux.Router     // audited routes, matched before authentication
+	done chan struct{}
 }
 
 func NewHandler(l *log.Logger) *Handler {
-	return &Handler{l}
+	h := &Handler{l: l, th: newHealthThresholds(l), pl: newPageLimits(l), cors: newCORS(l), kg: newKeyRotateGrace(l), so: newSignatureOptions(l), rl: newRateLimiter(l), sm: newShareMaxAge(l), sa: newSessionMaxAge(l)}
+	h.pub = publicPaths(h.routes())
+	h.ar = h.newAuditRouter()
+	h.api = newOpenAPI(l, h.routes(), h.rootRoutes())
+	h.gl = newGraphQLLimits(l, h.pl)
+
+	gql, err := h.newGraphQLSchema()
+	if err != nil {
+		l.Fatalf("Error building graphql schema: %s\n", err)
+	}
+	h.gql = gql
+
+	h.done = make(chan struct{})
+	go h.flushKeyUses()
+
+	return h
+}
+
+// keyUseInterval is the period of writes of the last use of keys
+const keyUseInterval = time.Minute
+
+// flushKeyUses writes the last use of keys to db each keyUseInterval, so
+// requests do not wait on db writes
+func (h *Handler) flushKeyUses() {
+	t := time.NewTicker(keyUseInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if err := h.rl.flush(); err != nil {
+				h.l.Printf("Error recording key use: %s\n", err)
+			}
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// Close stops the writes of the last use of keys and writes pending uses
+func (h *Handler) Close() error {
+	close(h.done)
+	return h.rl.flush()
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	gh "github.com/gorilla/handlers"
+)
+
+var (
+	corsOrigins = "" // comma separated, empty disables cors
+	corsMethods = "GET,POST,DELETE"
+	corsHeaders = "X-Api-Key,Content-Type,If-None-Match,If-Modified-Since,X-Request-Id,X-Api-Key-Id,X-Signature-Timestamp,X-Signature-Nonce,X-Signature,X-Node-Address,X-Share-Token,Authorization"
+	corsMaxAge  = "600"
+
+	corsCredentials = "false" // allow the session cookie on cors requests
+)
+
+// corsExposedHeaders are readable by the webui
+var corsExposedHeaders = []string{"ETag", "Last-Modified", "Link", "Retry-After", "X-Request-Id"}
+
+func newCORS(l *log.Logger) func(http.Handler) http.Handler {
+	origins := splitList(corsOrigins)
+	if len(origins) == 0 {
+		return nil
+	}
+
+	// override defaults if set
+	maxAge := 600
+	if v, err := strconv.Atoi(corsMaxAge); err == nil && v >= 0 {
+		maxAge = v
+	} else {
+		l.Printf("Error with cors max age, using default: %d\n", maxAge)
+	}
+
+	methods := splitList(strings.ToUpper(corsMethods))
+	headers := splitList(corsHeaders)
+	for i, v := range headers {
+		headers[i] = http.CanonicalHeaderKey(v)
+	}
+
+	opts := []gh.CORSOption{
+		gh.AllowedOrigins(origins),
+		gh.AllowedMethods(methods),
+		gh.AllowedHeaders(headers),
+		gh.ExposedHeaders(corsExposedHeaders),
+		gh.MaxAge(maxAge),
+	}
+
+	// credentials need explicit origins, browsers refuse them for any origin
+	if v, err := strconv.ParseBool(corsCredentials); err != nil || (v && indexOf(origins, "*") >= 0) {
+		l.Printf("Error with cors credentials, using default: false\n")
+	} else if v {
+		opts = append(opts, gh.AllowCredentials())
+	}
+
+	return gh.CORS(opts...)
+}
+
+// MiddlewareCORS answers preflight requests of the configured origins, so it
+// must run ahead of MiddlewareAuthz
+func (h *Handler) MiddlewareCORS(next http.Handler) http.Handler {
+	if h.cors == nil {
+		return next
+	}
+
+	cors := h.cors(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// responses differ by origin
+		w.Header().Add("Vary", "Origin")
+
+		// next handler
+		cors.ServeHTTP(w, r)
+	})
+}
+
+func splitList(s string) []string {
+	var l []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			l = append(l, v)
+		}
+	}
+
+	return l
+}
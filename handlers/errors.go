@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+type errorEnvelope struct {
+	Error *errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+func invalidArgument(msg string) error {
+	return data.Errorf(data.ErrInvalidArgument, "%s", msg)
+}
+
+// writeError maps data error kinds to http status codes
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrInvalidArgument):
+		h.writeStatusError(w, r, http.StatusBadRequest, "invalid_argument", err.Error())
+	case errors.Is(err, data.ErrPermissionDenied):
+		h.writeStatusError(w, r, http.StatusForbidden, "forbidden", err.Error())
+	case errors.Is(err, data.ErrNotFound):
+		h.writeStatusError(w, r, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, data.ErrUnavailable):
+		h.l.Printf("Error upstream unavailable: %s\n", err)
+		h.writeStatusError(w, r, http.StatusBadGateway, "upstream_unavailable", err.Error())
+	default:
+		h.l.Printf("Error internal: %s\n", err)
+		h.writeStatusError(w, r, http.StatusInternalServerError, "internal", "internal error")
+	}
+}
+
+func (h *Handler) writeStatusError(w http.ResponseWriter, r *http.Request, status int, code, msg string) {
+	// set http response headers, drop cache validators of the data
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Del("ETag")
+	w.Header().Del("Last-Modified")
+	w.WriteHeader(status)
+
+	// encode to json byte array
+	e := &errorEnvelope{&errorBody{status, code, msg, requestID(r)}}
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		h.l.Printf("Error encoding error response: %s\n", err)
+	}
+}
+
+func (h *Handler) NotFound(w http.ResponseWriter, r *http.Request) {
+	h.writeStatusError(w, r, http.StatusNotFound, "not_found", "error route not found")
+}
+
+func (h *Handler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	h.writeStatusError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "error method not allowed")
+}
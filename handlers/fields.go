@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+func parseFieldsQuery(r *http.Request, v interface{}) ([]string, error) {
+	q := r.URL.Query().Get("fields")
+	if q == "" {
+		return nil, nil
+	}
+
+	// map valid fields of list type
+	m := make(map[string]bool)
+	for _, f := range jsonFields(reflect.TypeOf(v)) {
+		m[f] = true
+	}
+
+	// remove duplicates, keep requested order
+	var fields []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(q, ",") {
+		f = strings.TrimSpace(f)
+		if !m[f] {
+			return nil, invalidArgument(fmt.Sprintf("error with fields param: %s", f))
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+func jsonFields(t reflect.Type) []string {
+	// unwrap pointers & slices to record struct
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var l []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			l = append(l, jsonFields(f.Type)...)
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		l = append(l, tag)
+	}
+
+	return l
+}
+
+// writeFields writes the records of a json array as objects holding the
+// selected fields in requested order
+func writeFields(w io.Writer, b []byte, fields []string) error {
+	var l []map[string]json.RawMessage
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteByte('[')
+	for i, m := range l {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		projectFields(out, m, fields)
+	}
+	out.WriteString("]\n")
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func projectFields(out *bytes.Buffer, m map[string]json.RawMessage, fields []string) {
+	out.WriteByte('{')
+	for j, f := range fields {
+		if j > 0 {
+			out.WriteByte(',')
+		}
+		fmt.Fprintf(out, "%q:", f)
+		if raw, ok := m[f]; ok {
+			out.Write(raw)
+		} else {
+			out.WriteString("null")
+		}
+	}
+	out.WriteByte('}')
+}
+
+// fieldsWriter projects each ndjson line written to it to the selected fields
+type fieldsWriter struct {
+	w      io.Writer
+	fields []string
+	buf    bytes.Buffer
+}
+
+func (fw *fieldsWriter) Write(p []byte) (int, error) {
+	fw.buf.Write(p)
+
+	// write complete lines
+	for {
+		i := bytes.IndexByte(fw.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(fw.buf.Next(i+1), &m); err != nil {
+			return 0, err
+		}
+
+		out := &bytes.Buffer{}
+		projectFields(out, m, fw.fields)
+		out.WriteByte('\n')
+		if _, err := fw.w.Write(out.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+}
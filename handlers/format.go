@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type listEncoder interface {
+	ToJSON(w io.Writer) error
+	ToNDJSON(w io.Writer) error
+	ToCSV(w io.Writer, fields ...string) error
+}
+
+type format struct {
+	name        string
+	contentType string
+}
+
+var (
+	formatJSON   = &format{"json", "application/json"}
+	formatNDJSON = &format{"ndjson", "application/x-ndjson"}
+	formatCSV    = &format{"csv", "text/csv; charset=utf-8"}
+)
+
+// formats in server preference order
+var formats = []*format{formatJSON, formatNDJSON, formatCSV}
+
+// formatMediaTypes maps accepted media types to formats
+var formatMediaTypes = map[string]*format{
+	"application/json":     formatJSON,
+	"application/x-ndjson": formatNDJSON,
+	"application/ndjson":   formatNDJSON,
+	"text/csv":             formatCSV,
+}
+
+// parseFormatQuery reads the format param, falls back to the accept header
+// and defaults to json
+func parseFormatQuery(r *http.Request) (*format, error) {
+	if q := r.URL.Query().Get("format"); q != "" {
+		for _, f := range formats {
+			if f.name == q {
+				return f, nil
+			}
+		}
+		return nil, invalidArgument(fmt.Sprintf("error with format param: %s", q))
+	}
+
+	return negotiateFormat(r.Header.Get("Accept")), nil
+}
+
+// negotiateFormat picks the supported media type with the highest quality,
+// ties and wildcards keep server preference order
+func negotiateFormat(accept string) *format {
+	best, bestQ := formatJSON, 0.0
+	for _, v := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		f, ok := formatMediaTypes[mt]
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ || (q == bestQ && f.pref() < best.pref()) {
+			best, bestQ = f, q
+		}
+	}
+
+	return best
+}
+
+func (f *format) pref() int {
+	for i, v := range formats {
+		if v == f {
+			return i
+		}
+	}
+
+	return len(formats)
+}
+
+// writeList encodes the list in the negotiated format, keeping the selected
+// fields only if set
+func writeList(w io.Writer, v listEncoder, q *listQuery) error {
+	switch q.format {
+	case formatCSV:
+		return v.ToCSV(w, q.fields...)
+
+	case formatNDJSON:
+		if len(q.fields) == 0 {
+			return v.ToNDJSON(w)
+		}
+		return v.ToNDJSON(&fieldsWriter{w: w, fields: q.fields})
+
+	default:
+		if len(q.fields) == 0 {
+			return v.ToJSON(w)
+		}
+
+		// decode records to raw fields
+		buf := &bytes.Buffer{}
+		if err := v.ToJSON(buf); err != nil {
+			return err
+		}
+		return writeFields(w, buf.Bytes(), q.fields)
+	}
+}
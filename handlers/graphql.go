@@ -0,0 +1,612 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	graphqlPath = "/graphql"
+)
+
+var (
+	graphqlMaxDepth      = "8"
+	graphqlMaxComplexity = "10000"
+)
+
+// graphqlListFields return lists paged by their limit argument
+var graphqlListFields = map[string]bool{
+	"nodes":        true,
+	"blocks":       true,
+	"broadcasts":   true,
+	"peer_history": true,
+	"misses":       true,
+	"incidents":    true,
+}
+
+type graphqlLimits struct {
+	maxDepth      int
+	maxComplexity int
+	pageLimit     int
+	maxLimit      int // resolvers clamp limits to the max page limit
+}
+
+func newGraphQLLimits(l *log.Logger, pl *pageLimits) *graphqlLimits {
+	gl := &graphqlLimits{8, 10000, pl.limit, pl.maxLimit}
+
+	// override defaults if set
+	if v, err := strconv.Atoi(graphqlMaxDepth); err == nil && v > 0 {
+		gl.maxDepth = v
+	} else {
+		l.Printf("Error with graphql max depth, using default: %d\n", gl.maxDepth)
+	}
+	if v, err := strconv.Atoi(graphqlMaxComplexity); err == nil && v > 0 {
+		gl.maxComplexity = v
+	} else {
+		l.Printf("Error with graphql max complexity, using default: %d\n", gl.maxComplexity)
+	}
+
+	return gl
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type graphqlErrors struct {
+	Errors []*graphqlError `json:"errors"`
+}
+
+// graphqlNode is the source of node fields, health is kept if already read
+type graphqlNode struct {
+	Addr string `json:"address"`
+	hs   *data.Health
+}
+
+func (h *Handler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	// get request params
+	req := &graphqlRequest{}
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				h.writeGraphQLError(w, r, http.StatusBadRequest, "error with variables param")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		h.writeGraphQLError(w, r, http.StatusBadRequest, fmt.Sprintf("error with request body: %s", err))
+		return
+	}
+
+	// validate query limits
+	if err := h.gl.check(req); err != nil {
+		h.writeGraphQLError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// execute query
+	res := graphql.Do(graphql.Params{
+		Schema:         *h.gql,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+
+	// add request id to errors, as in error responses
+	for i, e := range res.Errors {
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]interface{})
+		}
+		e.Extensions["request_id"] = requestID(r)
+		res.Errors[i] = e
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) writeGraphQLError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	// encode to json byte array
+	if err := json.NewEncoder(w).Encode(&graphqlErrors{[]*graphqlError{{msg, map[string]interface{}{"request_id": requestID(r)}}}}); err != nil {
+		h.l.Printf("Error encoding error response: %s\n", err)
+	}
+}
+
+// check rejects queries nested deeper than max depth or costing more than max
+// complexity, a field costs 1 plus its selections times its page limit
+func (gl *graphqlLimits) check(req *graphqlRequest) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return nil // reported by graphql execution
+	}
+
+	// map fragments
+	frags := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if fd, ok := def.(*ast.FragmentDefinition); ok {
+			frags[fd.Name.Value] = fd
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		od, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		cost, depth := gl.cost(od.SelectionSet, frags, req.Variables, make(map[string]bool))
+		if depth > gl.maxDepth {
+			return fmt.Errorf("error query depth %d exceeds max depth %d", depth, gl.maxDepth)
+		}
+		if cost > gl.maxComplexity {
+			return fmt.Errorf("error query complexity %d exceeds max complexity %d, set lower limits", cost, gl.maxComplexity)
+		}
+	}
+
+	return nil
+}
+
+func (gl *graphqlLimits) cost(set *ast.SelectionSet, frags map[string]*ast.FragmentDefinition, vars map[string]interface{}, seen map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	var cost, depth int
+	for _, sel := range set.Selections {
+		var c, d int
+		switch v := sel.(type) {
+		case *ast.Field:
+			c, d = gl.cost(v.SelectionSet, frags, vars, seen)
+			c, d = addCost(1, mulCost(gl.multiplier(v, vars), c)), d+1
+		case *ast.InlineFragment:
+			c, d = gl.cost(v.SelectionSet, frags, vars, seen)
+		case *ast.FragmentSpread:
+			fd, ok := frags[v.Name.Value]
+			if !ok || seen[v.Name.Value] {
+				continue // cycles reported by graphql validation
+			}
+			seen[v.Name.Value] = true
+			c, d = gl.cost(fd.SelectionSet, frags, vars, seen)
+			delete(seen, v.Name.Value)
+		}
+
+		cost = addCost(cost, c)
+		if d > depth {
+			depth = d
+		}
+	}
+
+	return cost, depth
+}
+
+// multiplier is the number of records a field may return, within 1 and the
+// max page limit
+func (gl *graphqlLimits) multiplier(f *ast.Field, vars map[string]interface{}) int {
+	n := 1
+	if graphqlListFields[f.Name.Value] {
+		n = gl.pageLimit
+	}
+	for _, arg := range f.Arguments {
+		switch arg.Name.Value {
+		case "limit":
+			if v := argInt(arg.Value, vars); v > 0 {
+				n = v
+			}
+		case "addresses":
+			if lv, ok := arg.Value.(*ast.ListValue); ok {
+				n = len(lv.Values)
+			}
+		}
+	}
+
+	if n < 1 {
+		return 1
+	}
+	if n > gl.maxLimit {
+		return gl.maxLimit
+	}
+	return n
+}
+
+// addCost & mulCost saturate at math.MaxInt instead of overflowing
+func addCost(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func mulCost(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+func argInt(v ast.Value, vars map[string]interface{}) int {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, _ := strconv.Atoi(v.Value) // out of range literals are clamped
+		return n
+	case *ast.Variable:
+		if f, ok := vars[v.Name.Value].(float64); ok {
+			if f >= math.MaxInt32 {
+				return math.MaxInt32
+			}
+			return int(f)
+		}
+	}
+
+	return 0
+}
+
+// newGraphQLSchema describes nodes, broadcasts, peers & blocks resolved
+// through the data types
+func (h *Handler) newGraphQLSchema() (*graphql.Schema, error) {
+	gb := &graphqlBuilder{make(map[string]*graphql.Object)}
+
+	umType := gb.object(reflect.TypeOf(data.UMBroadcast{}), nil)
+	p2pType := gb.object(reflect.TypeOf(data.P2P{}), nil)
+	bkType := gb.object(reflect.TypeOf(data.Block{}), nil)
+	hsType := gb.object(reflect.TypeOf(data.Health{}), nil)
+	usType := gb.object(reflect.TypeOf(data.UptimeSummary{}), nil)
+	piType := gb.object(reflect.TypeOf(data.PeerIncident{}), nil)
+
+	nodeType := gb.object(reflect.TypeOf(graphqlNode{}), graphql.Fields{
+		"health": &graphql.Field{
+			Type: hsType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				nd := p.Source.(*graphqlNode)
+				if nd.hs != nil {
+					return nd.hs, nil
+				}
+				hs := data.NewHealth()
+				return h.resolveOne(hs, hs.GetHealthByAddr(nd.Addr, h.th))
+			},
+		},
+		"broadcast": &graphql.Field{
+			Type: umType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				uml := data.NewUMBroadcasts()
+				if err := uml.GetUMBroadcastsByCluster(p.Source.(*graphqlNode).Addr, nil); err != nil || len(*uml) == 0 {
+					return h.resolveOne(nil, err)
+				}
+				return (*uml)[0], nil
+			},
+		},
+		"peers": &graphql.Field{
+			Type: p2pType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				p2pl := data.NewP2Ps()
+				if err := p2pl.GetNumPeersByCluster(p.Source.(*graphqlNode).Addr, nil); err != nil || len(*p2pl) == 0 {
+					return h.resolveOne(nil, err)
+				}
+				return (*p2pl)[0], nil
+			},
+		},
+		"broadcasts": &graphql.Field{
+			Type: graphql.NewList(umType),
+			Args: listArgs(false),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				pg, err := h.graphqlPage(p)
+				if err != nil {
+					return nil, err
+				}
+				addr, min, max := p.Source.(*graphqlNode).Addr, argString(p, "min"), argString(p, "max")
+				uml := data.NewUMBroadcasts()
+				if min == "" {
+					return h.resolveList(uml, uml.GetUMBroadcastsByAddr(addr, pg))
+				}
+				return h.resolveList(uml, uml.GetUMBroadcastsByAddrByRange(addr, min, max, pg))
+			},
+		},
+		"peer_history": &graphql.Field{
+			Type: graphql.NewList(p2pType),
+			Args: listArgs(false),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				pg, err := h.graphqlPage(p)
+				if err != nil {
+					return nil, err
+				}
+				addr, min, max := p.Source.(*graphqlNode).Addr, argString(p, "min"), argString(p, "max")
+				p2pl := data.NewP2Ps()
+				if min == "" {
+					return h.resolveList(p2pl, p2pl.GetNumPeersByAddr(addr, pg))
+				}
+				return h.resolveList(p2pl, p2pl.GetNumPeersByAddrByRange(addr, min, max, pg))
+			},
+		},
+		"misses": &graphql.Field{
+			Type: graphql.NewList(bkType),
+			Args: listArgs(true),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				pg, err := h.graphqlPage(p)
+				if err != nil {
+					return nil, err
+				}
+				vkl := data.NewBlocks()
+				return h.resolveList(vkl, vkl.GetMissedBlocksByAddrByRange(p.Source.(*graphqlNode).Addr, argString(p, "min"), argString(p, "max"), pg))
+			},
+		},
+		"summary": &graphql.Field{
+			Type: usType,
+			Args: graphql.FieldConfigArgument{
+				"min":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"max":   &graphql.ArgumentConfig{Type: graphql.String},
+				"daily": &graphql.ArgumentConfig{Type: graphql.Boolean},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				daily, _ := p.Args["daily"].(bool)
+				us := data.NewUptimeSummary()
+				return h.resolveOne(us, us.GetUptimeSummaryByAddrByRange(p.Source.(*graphqlNode).Addr, argString(p, "min"), argString(p, "max"), daily))
+			},
+		},
+		"incidents": &graphql.Field{
+			Type: graphql.NewList(piType),
+			Args: listArgs(true),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				pg, err := h.graphqlPage(p)
+				if err != nil {
+					return nil, err
+				}
+				pil := data.NewPeerIncidents()
+				return h.resolveList(pil, pil.GetPeerIncidentsByAddrByRange(p.Source.(*graphqlNode).Addr, argString(p, "min"), argString(p, "max"), pg))
+			},
+		},
+	})
+
+	nodesArgs := listArgs(false)
+	delete(nodesArgs, "min")
+	delete(nodesArgs, "max")
+	nodesArgs["addresses"] = &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"node": &graphql.Field{
+				Type: nodeType,
+				Args: graphql.FieldConfigArgument{
+					"address": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := checkAddr(p.Context, argString(p, "address")); err != nil {
+						return nil, h.resolveError(err)
+					}
+					hs := data.NewHealth()
+					if err := hs.GetHealthByAddr(argString(p, "address"), h.th); err != nil {
+						return h.resolveOne(nil, err)
+					}
+					return &graphqlNode{hs.Addr, hs}, nil
+				},
+			},
+			"nodes": &graphql.Field{
+				Type: graphql.NewList(nodeType),
+				Args: nodesArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pg, err := h.graphqlPage(p)
+					if err != nil {
+						return nil, err
+					}
+					pg.Match = addrMatch(p.Context) // filter addresses of api key
+
+					// nodes of cluster
+					if addrs, ok := p.Args["addresses"].([]interface{}); ok {
+						var l []*graphqlNode
+						for _, v := range addrs {
+							if len(l) < pg.Limit && checkAddr(p.Context, v.(string)) == nil {
+								l = append(l, &graphqlNode{Addr: v.(string)})
+							}
+						}
+						return l, nil
+					}
+
+					// all nodes
+					hl := data.NewHealths()
+					if err := hl.GetHealths(h.th, pg); err != nil {
+						return nil, h.resolveError(err)
+					}
+					var l []*graphqlNode
+					for _, hs := range *hl {
+						l = append(l, &graphqlNode{hs.Addr, hs})
+					}
+					return l, nil
+				},
+			},
+			"block": &graphql.Field{
+				Type: bkType,
+				Args: graphql.FieldConfigArgument{
+					"height": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					height, _ := p.Args["height"].(int)
+					bk := data.NewBlock()
+					return h.resolveOne(bk, bk.GetBlockByHeight(height))
+				},
+			},
+			"blocks": &graphql.Field{
+				Type: graphql.NewList(bkType),
+				Args: listArgs(false),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					pg, err := h.graphqlPage(p)
+					if err != nil {
+						return nil, err
+					}
+					vkl := data.NewBlocks()
+					if min := argString(p, "min"); min != "" {
+						return h.resolveList(vkl, vkl.GetBlocksByRange(min, argString(p, "max"), pg))
+					}
+					return h.resolveList(vkl, vkl.GetBlocks(pg))
+				},
+			},
+		},
+	})
+
+	sc, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sc, nil
+}
+
+func listArgs(minRequired bool) graphql.FieldConfigArgument {
+	var min graphql.Input = graphql.String
+	if minRequired {
+		min = graphql.NewNonNull(graphql.String)
+	}
+
+	return graphql.FieldConfigArgument{
+		"min":   &graphql.ArgumentConfig{Type: min, Description: "range start as RFC3339 time or Unix seconds"},
+		"max":   &graphql.ArgumentConfig{Type: graphql.String, Description: "range end as RFC3339 time or Unix seconds"},
+		"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+		"order": &graphql.ArgumentConfig{Type: graphql.String},
+	}
+}
+
+func argString(p graphql.ResolveParams, key string) string {
+	v, _ := p.Args[key].(string)
+	return v
+}
+
+func (h *Handler) graphqlPage(p graphql.ResolveParams) (*data.Page, error) {
+	limit, ok := p.Args["limit"].(int)
+	if !ok {
+		limit = h.pl.limit
+	}
+	if limit < 1 {
+		return nil, errors.New("error with limit param")
+	}
+	if limit > h.pl.maxLimit {
+		limit = h.pl.maxLimit
+	}
+
+	order, _ := p.Args["order"].(string)
+	pg, err := data.NewPage(limit, "", order)
+	if err != nil {
+		return nil, h.resolveError(err)
+	}
+
+	return pg, nil
+}
+
+// resolveOne returns v, or null if not found
+func (h *Handler) resolveOne(v interface{}, err error) (interface{}, error) {
+	if errors.Is(err, data.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, h.resolveError(err)
+	}
+
+	return v, nil
+}
+
+// resolveList returns the slice of list type v
+func (h *Handler) resolveList(v interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, h.resolveError(err)
+	}
+
+	return reflect.ValueOf(v).Elem().Interface(), nil
+}
+
+// resolveError hides internal error messages like writeError
+func (h *Handler) resolveError(err error) error {
+	if errors.Is(err, data.ErrInvalidArgument) || errors.Is(err, data.ErrNotFound) || errors.Is(err, data.ErrUnavailable) || errors.Is(err, data.ErrPermissionDenied) {
+		return err
+	}
+
+	h.l.Printf("Error internal: %s\n", err)
+	return errors.New("internal error")
+}
+
+// graphqlBuilder describes data types by their json fields
+type graphqlBuilder struct {
+	objects map[string]*graphql.Object
+}
+
+func (gb *graphqlBuilder) object(t reflect.Type, extra graphql.Fields) *graphql.Object {
+	name := strings.TrimPrefix(t.Name(), "graphql")
+	if obj, ok := gb.objects[name]; ok {
+		return obj
+	}
+
+	fields := graphql.Fields{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "" || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		if ft := gb.output(f.Type); ft != nil {
+			fields[tag] = &graphql.Field{Type: ft}
+		}
+	}
+	for k, v := range extra {
+		fields[k] = v
+	}
+
+	obj := graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: fields})
+	gb.objects[name] = obj
+	return obj
+}
+
+func (gb *graphqlBuilder) output(t reflect.Type) graphql.Output {
+	if t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(&time.Time{}) {
+		return graphql.DateTime
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return gb.output(t.Elem())
+	case reflect.Slice:
+		if et := gb.output(t.Elem()); et != nil {
+			return graphql.NewList(et)
+		}
+	case reflect.Struct:
+		return gb.object(t, nil)
+	case reflect.String:
+		return graphql.String
+	case reflect.Bool:
+		return graphql.Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return graphql.Int
+	case reflect.Float32, reflect.Float64:
+		return graphql.Float
+	}
+
+	return nil // interfaces are not described
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import "testing"
+
+func TestGraphQLLimitsCheck(t *testing.T) {
+	gl := &graphqlLimits{8, 10000, 500, 1000}
+
+	if err := gl.check(&graphqlRequest{Query: "{ nodes(limit: 10) { address } }"}); err != nil {
+		t.Fatalf("handlers.check() returned error: %v", err)
+	}
+
+	// test limits that overflow the cost
+	for _, q := range []string{
+		"{ nodes(limit: 9223372036854775807) { address broadcasts(limit: 9223372036854775807) { height } } }",
+		"{ nodes(limit: -1) { broadcasts { height } } }",
+	} {
+		if err := gl.check(&graphqlRequest{Query: q}); err == nil {
+			t.Fatalf("handlers.check(%q) returned: %v, wanted error", q, err)
+		}
+	}
+
+	// test variable limits
+	req := &graphqlRequest{
+		Query:     "query($n: Int) { nodes(limit: $n) { broadcasts(limit: $n) { height } } }",
+		Variables: map[string]interface{}{"n": 1e300},
+	}
+	if err := gl.check(req); err == nil {
+		t.Fatalf("handlers.check() returned: %v, wanted error", err)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+var (
+	healthDegradedHeights = "200"
+	healthOfflineHeights  = "1000"
+)
+
+func newHealthThresholds(l *log.Logger) *data.HealthThresholds {
+	th := data.NewHealthThresholds()
+
+	// override defaults if set
+	if v, err := strconv.Atoi(healthDegradedHeights); err == nil {
+		th.DegradedHeights = v
+	} else {
+		l.Printf("Error with health degraded heights, using default: %s\n", err)
+	}
+	if v, err := strconv.Atoi(healthOfflineHeights); err == nil {
+		th.OfflineHeights = v
+	} else {
+		l.Printf("Error with health offline heights, using default: %s\n", err)
+	}
+
+	return th
+}
+
+func (h *Handler) GetHealths(w http.ResponseWriter, r *http.Request) {
+	// get list params
+	q, err := h.parseListQuery(r, &data.Healths{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	q.pg.Match = addrMatch(r.Context()) // filter addresses of api key
+
+	// get last write time before data
+	md := data.NewModified()
+	if err := md.GetHealthsModified(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, md.Time) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// get data from db
+	hl := data.NewHealths()
+	if err := hl.GetHealths(h.th, q.pg); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
+
+	// encode to response format
+	if err := writeList(w, hl, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetHealthByAddr(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// validate params
+	if len(pp) != 1 {
+		h.writeError(w, r, invalidArgument("error with request params"))
+		return
+	}
+	if err := isValidAddr(pp["addr"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+
+	// get last write time before data
+	md := data.NewModified()
+	if err := md.GetHealthsModified(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, md.Time) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// get data from db
+	hs := data.NewHealth()
+	if err := hs.GetHealthByAddr(pp["addr"], h.th); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := hs.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
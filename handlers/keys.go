@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+var (
+	keyRotateGrace = "24h"
+)
+
+func newKeyRotateGrace(l *log.Logger) time.Duration {
+	// override default if set
+	v, err := time.ParseDuration(keyRotateGrace)
+	if err != nil || v < 0 {
+		l.Printf("Error with key rotate grace, using default: 24h\n")
+		return 24 * time.Hour
+	}
+
+	return v
+}
+
+// keyRoutes are the names of the routes a key may be limited to
+func (h *Handler) keyRoutes() []string {
+	var l []string
+	for _, rt := range append(h.routes(), h.rootRoutes()...) {
+		if rt.scope != scopePublic && indexOf(l, rt.name) < 0 {
+			l = append(l, rt.name)
+		}
+	}
+
+	return l
+}
+
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	// get request body
+	k := data.NewAPIKey()
+	if err := k.FromJSON(r.Body); err != nil {
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+
+	// validate params
+	routes := h.keyRoutes()
+	for _, v := range k.Routes {
+		if indexOf(routes, v) < 0 {
+			h.writeError(w, r, invalidArgument("error with key route: "+v))
+			return
+		}
+	}
+
+	// update db collection
+	token, err := k.CreateAPIKey()
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	setAuditTarget(r, k.ID)
+	sk, err := data.SigningKey(token)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+
+	// encode to json byte array
+	k.Hash = ""
+	kt := &data.APIKeyToken{APIKey: *k, Token: token, SigningKey: sk}
+	if err := kt.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	// get data from db
+	kl := data.NewAPIKeys()
+	if err := kl.GetAPIKeys(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := kl.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetAPIKey(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// get data from db
+	k := data.NewAPIKey()
+	if err := k.GetAPIKey(pp["id"]); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := k.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// update db collection
+	k := data.NewAPIKey()
+	if err := k.RevokeAPIKey(pp["id"]); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := k.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// validate params
+	grace := h.kg
+	if v := r.URL.Query().Get("grace"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			h.writeError(w, r, invalidArgument("error with grace"))
+			return
+		}
+		grace = d
+	}
+
+	// update db collection
+	k := data.NewAPIKey()
+	token, err := k.RotateAPIKey(pp["id"], grace)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	sk, err := data.SigningKey(token)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	// encode to json byte array
+	kt := &data.APIKeyToken{APIKey: *k, Token: token, SigningKey: sk}
+	if err := kt.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+// clientIP is the remote ip of a request, proxy headers are not trusted
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -1,30 +1,232 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+type ctxKey int
+
+const (
+	ctxRequestID ctxKey = iota
+	ctxAPIKey
+	ctxSigned
+	ctxAudit
 )
 
 var (
-	apiKeyHdr = "X-Api-Key"
-	apiKey    = "devkey"
+	apiKeyHdr    = "X-Api-Key"
+	apiKey       = "" // admin key set at build time, empty disables it
+	requestIDHdr = "X-Request-Id"
+	requestIDRe  = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
 )
 
+func (h *Handler) MiddlewareRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// reuse client request id if valid
+		id := r.Header.Get(requestIDHdr)
+		if !requestIDRe.MatchString(id) {
+			id = newRequestID()
+		}
+
+		// set http response headers
+		w.Header().Set(requestIDHdr, id)
+
+		// next handler
+		ctx := context.WithValue(r.Context(), ctxRequestID, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (h *Handler) MiddlewareAuthz(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// authorize request
-		k := r.Header.Get(apiKeyHdr)
-		if ok := verifyAPIKey(k); !ok {
-			http.Error(w, "error not authorized", http.StatusForbidden)
+		// public routes, such as login, are called without api key
+		if h.pub[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// deny ips over the limit of failed authentications before trying more
+		ip := clientIP(r)
+		if wait := h.rl.failed(ip); wait > 0 {
+			h.auditDenied(w, r, func(w http.ResponseWriter) {
+				h.writeRateLimited(w, r, wait, "error too many failed authentications")
+			})
+			return
+		}
+
+		// authenticate request by signature, share, session, client certificate
+		// or token
+		var k *data.APIKey
+		var err error
+		if isSigned(r) {
+			k, err = h.authenticateSigned(w, r)
+		} else if t := shareToken(r); t != "" {
+			k, err = h.authenticateShare(t)
+		} else if t := sessionToken(r); t != "" && r.Header.Get(apiKeyHdr) == "" {
+			k, err = authenticateSession(t)
+		} else if sub := clientSubject(r); sub != "" && r.Header.Get(apiKeyHdr) == "" {
+			k = data.NewAPIKey()
+			err = k.GetAPIKeyBySubject(sub)
+		} else {
+			k, err = authenticate(r.Header.Get(apiKeyHdr))
+		}
+		if err != nil {
+			if errors.Is(err, data.ErrPermissionDenied) {
+				h.rl.fail(ip)
+			}
+			h.auditDenied(w, r, func(w http.ResponseWriter) { h.writeError(w, r, err) })
 			return
 		}
 
+		// record last use of stored keys
+		if k.Stored() {
+			h.rl.touch(k.ID, ip)
+		}
+
 		// next handler
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), ctxAPIKey, k)
+		ctx = context.WithValue(ctx, ctxSigned, isSigned(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// requireScope denies requests whose api key lacks scope, unsigned ingest
+// requests if signatures are required, and requests over the scope rate limit
+func (h *Handler) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if k := apiKeyFrom(r); scope != scopePublic && (k == nil || !k.HasScope(scope)) {
+			h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error missing scope: %s", scope))
+			return
+		}
+		if scope == data.ScopeIngest && h.so.required && !signedFrom(r.Context()) {
+			h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error signature required"))
+			return
+		}
+		if h.limitRate(w, r, scope) {
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requireRoute denies requests whose api key is limited to other routes
+func (h *Handler) requireRoute(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if k := apiKeyFrom(r); k == nil || !k.AllowsRoute(name) {
+			h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error route not allowed: %s", name))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requireAddr denies requests for an {addr} path param the api key may not see
+func (h *Handler) requireAddr(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := mux.Vars(r)["addr"]; ok {
+			if err := checkAddr(r.Context(), addr); err != nil {
+				h.writeError(w, r, err)
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(ctxRequestID).(string)
+	return id
+}
+
+// keyID identifies the api key of a request, the key set at build time is
+// named build and requests of public routes are anonymous
+func keyID(k *data.APIKey) string {
+	if k == nil {
+		return "anonymous"
+	}
+	if k.ID == "" {
+		return "build"
+	}
+
+	return k.ID
+}
+
+func apiKeyFrom(r *http.Request) *data.APIKey {
+	return apiKeyFromContext(r.Context())
+}
+
+func apiKeyFromContext(ctx context.Context) *data.APIKey {
+	k, _ := ctx.Value(ctxAPIKey).(*data.APIKey)
+	return k
+}
+
+// checkAddr denies addresses outside of the api key
+func checkAddr(ctx context.Context, addr string) error {
+	if k := apiKeyFromContext(ctx); k == nil || !k.AllowsAddr(addr) {
+		return data.Errorf(data.ErrPermissionDenied, "error address not allowed: %s", addr)
+	}
+
+	return nil
+}
+
+// addrMatch returns the address filter of lists read with the api key, nil
+// if the key sees all addresses
+func addrMatch(ctx context.Context) func(string) bool {
+	if k := apiKeyFromContext(ctx); k != nil && len(k.Addresses) > 0 {
+		return k.AllowsAddr
+	}
+
+	return nil
+}
+
+// clientSubject is the subject of a verified mutual tls client certificate
+func clientSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	return r.TLS.VerifiedChains[0][0].Subject.String()
+}
+
+// authenticate reads the key of a token, the build time api key is an admin
+// key if set
+func authenticate(token string) (*data.APIKey, error) {
+	if verifyAPIKey(token) {
+		return &data.APIKey{Name: "build", Scopes: []string{data.ScopeAdmin}}, nil
+	}
+
+	k := data.NewAPIKey()
+	if err := k.GetAPIKeyByToken(token); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
 func verifyAPIKey(k string) bool {
-	return k == apiKey
+	return apiKey != "" && subtle.ConstantTimeCompare([]byte(k), []byte(apiKey)) == 1
 }
 
 func isValidAddr(addr string) error {
@@ -38,3 +240,21 @@ func areValidAddrs(addrs string) error {
 func areValidTimes(min, max string) error {
 	return nil
 }
+
+func parseBoolQuery(r *http.Request, key string) (bool, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(v)
+}
+
+func parseIntQuery(r *http.Request, key string, def int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(v)
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+const (
+	openapiPath    = "/openapi.json"
+	openapiVersion = "3.0.3"
+	openapiTitle   = "EdgeStats Server API"
+	openapiRelease = "1.0.0"
+)
+
+var (
+	openapiValidate = "false"
+)
+
+var pathParamRe = regexp.MustCompile(`{(\w+)}`)
+
+type openAPI struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       *openAPIInfo                     `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components *components                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas         map[string]*schema         `json:"schemas"`
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in"`
+	Name string `json:"name"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	Minimum    *int               `json:"minimum,omitempty"`
+	Items      *schema            `json:"items,omitempty"`
+	Properties map[string]*schema `json:"properties,omitempty"`
+}
+
+// routeDoc describes a route beyond its method and path
+type routeDoc struct {
+	summary string
+	list    bool // accepts list params
+	query   []*parameter
+	body    interface{} // request body type
+	resp    interface{} // response body type
+	status  int
+	cached  bool // answers conditional requests
+}
+
+var pathParamDocs = map[string]string{
+	"addr":  "node address",
+	"addrs": "comma separated node addresses",
+	"min":   "range start as RFC3339 time or Unix seconds",
+	"max":   "range end as RFC3339 time or Unix seconds, defaults to now",
+	"id":    "api key or share id",
+	"name":  "username",
+}
+
+func listParams() []*parameter {
+	one := 1
+	return []*parameter{
+		{Name: "limit", In: "query", Description: "maximum number of records", Schema: &schema{Type: "integer", Minimum: &one}},
+		{Name: "cursor", In: "query", Description: "opaque cursor of the next page", Schema: &schema{Type: "string"}},
+		{Name: "order", In: "query", Description: "sort order", Schema: &schema{Type: "string", Enum: []string{data.OrderAsc, data.OrderDesc}}},
+		{Name: "fields", In: "query", Description: "comma separated fields to return", Schema: &schema{Type: "string"}},
+		{Name: "format", In: "query", Description: "response format, overrides the accept header", Schema: &schema{Type: "string", Enum: []string{formatJSON.name, formatNDJSON.name, formatCSV.name}}},
+	}
+}
+
+func auditParams() []*parameter {
+	one := 1
+	return []*parameter{
+		{Name: "actor", In: "query", Description: "api key id", Schema: &schema{Type: "string"}},
+		{Name: "action", In: "query", Description: "route name", Schema: &schema{Type: "string"}},
+		{Name: "min", In: "query", Description: "range start as RFC3339 time or Unix seconds", Schema: &schema{Type: "string"}},
+		{Name: "max", In: "query", Description: "range end as RFC3339 time or Unix seconds", Schema: &schema{Type: "string"}},
+		{Name: "limit", In: "query", Description: "maximum number of records", Schema: &schema{Type: "integer", Minimum: &one}},
+		{Name: "cursor", In: "query", Description: "opaque cursor of the next page", Schema: &schema{Type: "string"}},
+		{Name: "order", In: "query", Description: "sort order", Schema: &schema{Type: "string", Enum: []string{data.OrderAsc, data.OrderDesc}}},
+	}
+}
+
+var routeDocs = map[string]*routeDoc{
+	"GetHealths":                    {summary: "List node health", list: true, resp: data.Healths{}, cached: true},
+	"GetHealthByAddr":               {summary: "Get node health", resp: data.Health{}, cached: true},
+	"GetUMBroadcastsByAddr":         {summary: "List node broadcasts", list: true, resp: data.UMBroadcasts{}},
+	"GetUMBroadcastsByAddrByRange":  {summary: "List node broadcasts by time range", list: true, resp: data.UMBroadcasts{}},
+	"GetNumPeersByAddr":             {summary: "List node peers", list: true, resp: data.P2Ps{}},
+	"GetNumPeersByAddrByRange":      {summary: "List node peers by time range", list: true, resp: data.P2Ps{}},
+	"GetMissedBlocksByAddrByRange":  {summary: "List node missed blocks by time range", list: true, resp: data.Blocks{}},
+	"GetUptimeSummaryByAddrByRange": {summary: "Get node uptime summary by time range", query: []*parameter{{Name: "daily", In: "query", Description: "include daily breakdown", Schema: &schema{Type: "boolean"}}}, resp: data.UptimeSummary{}},
+	"GetPeerIncidentsByAddrByRange": {summary: "List node peer incidents by time range", list: true, resp: data.PeerIncidents{}},
+	"GetTimelineByAddrByRange":      {summary: "List node events by time range", list: true, resp: data.Timeline{}},
+	"CreateUMBroadcast":             {summary: "Create broadcast", body: data.UMBroadcast{}, status: http.StatusCreated},
+	"GetUMBroadcasts":               {summary: "List latest broadcast of each node", list: true, resp: data.UMBroadcasts{}, cached: true},
+	"CreateNumPeers":                {summary: "Create peers", body: data.P2P{}, status: http.StatusCreated},
+	"GetNumPeers":                   {summary: "List latest peers of each node", list: true, resp: data.P2Ps{}, cached: true},
+	"CreateHeartbeat":               {summary: "Create broadcast and peers", body: data.Heartbeat{}, resp: data.Health{}, status: http.StatusCreated},
+	"GetUMBroadcastsByCluster":      {summary: "List latest broadcasts of nodes", list: true, resp: data.UMBroadcasts{}, cached: true},
+	"GetNumPeersByCluster":          {summary: "List latest peers of nodes", list: true, resp: data.P2Ps{}, cached: true},
+	"CreateBlock":                   {summary: "Create block", body: data.Block{}, status: http.StatusCreated},
+	"GetBlocks":                     {summary: "List blocks", list: true, resp: data.Blocks{}},
+	"GetBlocksByRange":              {summary: "List blocks by time range", list: true, resp: data.Blocks{}},
+	"CreateAPIKey":                  {summary: "Create api key, the token is only returned once", body: data.APIKey{}, resp: data.APIKeyToken{}, status: http.StatusCreated},
+	"GetAPIKeys":                    {summary: "List api keys", resp: data.APIKeys{}},
+	"GetAPIKey":                     {summary: "Get api key", resp: data.APIKey{}},
+	"RevokeAPIKey":                  {summary: "Revoke api key", resp: data.APIKey{}},
+	"RotateAPIKey":                  {summary: "Rotate api key secret, the former secret is accepted during grace", query: []*parameter{{Name: "grace", In: "query", Description: "duration the former secret is accepted, e.g. 1h", Schema: &schema{Type: "string"}}}, resp: data.APIKeyToken{}},
+	"GetKeyUsage":                   {summary: "Get api key requests since server start", resp: keyUsage{}},
+	"GetKeyUsages":                  {summary: "List api key requests since server start", resp: []keyUsage{}},
+	"CreateShare":                   {summary: "Create share token of read routes for addresses, the token is only returned once", body: data.Share{}, resp: data.ShareToken{}, status: http.StatusCreated},
+	"GetShares":                     {summary: "List shares", resp: data.Shares{}},
+	"GetAuditEntries":               {summary: "List audit entries of write and admin calls, newest first", query: auditParams(), resp: data.AuditEntries{}},
+	"RevokeShare":                   {summary: "Revoke share", resp: data.Share{}},
+	"Login":                         {summary: "Create session of user, the token is also set as cookie", body: loginRequest{}, resp: data.SessionToken{}, status: http.StatusCreated},
+	"Logout":                        {summary: "Revoke session of request", resp: data.Session{}},
+	"GetSession":                    {summary: "Get session of request", resp: data.Session{}},
+	"CreateUser":                    {summary: "Create user with role & password", body: data.User{}, resp: data.User{}, status: http.StatusCreated},
+	"GetUsers":                      {summary: "List users", resp: data.Users{}},
+	"GetUser":                       {summary: "Get user", resp: data.User{}},
+	"DisableUser":                   {summary: "Disable user and its sessions", resp: data.User{}},
+	"GetOpenAPI":                    {summary: "Get OpenAPI document", resp: map[string]interface{}{}},
+	"GraphQL":                       {summary: "Query nodes, broadcasts, peers and blocks with GraphQL", body: graphqlRequest{}, resp: map[string]interface{}{}},
+}
+
+// newOpenAPI describes the versioned routes, their aliases and the root routes
+func newOpenAPI(l *log.Logger, rts, roots []*route) *openAPI {
+	sp := &openAPI{
+		OpenAPI: openapiVersion,
+		Info:    &openAPIInfo{openapiTitle, openapiRelease},
+		Paths:   make(map[string]map[string]*operation),
+		Components: &components{
+			Schemas:         make(map[string]*schema),
+			SecuritySchemes: map[string]*securityScheme{"apiKey": {"apiKey", "header", apiKeyHdr}, "signature": {"apiKey", "header", signatureHdr}, "share": {"apiKey", "header", shareTokenHdr}, "session": {"apiKey", "cookie", sessionCookie}},
+		},
+	}
+
+	ids := make(map[string]int)
+	add := func(path string, rt *route, legacy bool) {
+		doc, ok := routeDocs[rt.name]
+		if !ok {
+			l.Printf("Error with openapi docs, missing route: %s\n", rt.name)
+			doc = &routeDoc{}
+		}
+
+		// make operation ids unique
+		id := rt.name
+		if legacy {
+			id += "Legacy"
+		}
+		ids[id]++
+		if n := ids[id]; n > 1 {
+			id += strconv.Itoa(n)
+		}
+
+		if sp.Paths[path] == nil {
+			sp.Paths[path] = make(map[string]*operation)
+		}
+		op := sp.newOperation(id, path, doc, legacy)
+		if rt.scope == scopePublic {
+			op.Security = []map[string][]string{} // no api key
+		}
+		sp.Paths[path][strings.ToLower(rt.method)] = op
+	}
+
+	for _, rt := range rts {
+		add(apiVersion+rt.path, rt, false)
+	}
+	for _, rt := range rts {
+		for _, p := range rt.aliases {
+			add(p, rt, true)
+		}
+	}
+	for _, rt := range roots {
+		add(rt.path, rt, false)
+	}
+
+	return sp
+}
+
+func (sp *openAPI) newOperation(id, path string, doc *routeDoc, legacy bool) *operation {
+	op := &operation{
+		OperationID: id,
+		Summary:     doc.summary,
+		Deprecated:  legacy,
+		Responses:   make(map[string]*response),
+		Security:    []map[string][]string{{"apiKey": {}}, {"signature": {}}, {"share": {}}, {"session": {}}},
+	}
+
+	// path params
+	for _, m := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, &parameter{Name: m[1], In: "path", Description: pathParamDocs[m[1]], Required: true, Schema: &schema{Type: "string"}})
+	}
+
+	// query params
+	if doc.list {
+		op.Parameters = append(op.Parameters, listParams()...)
+	}
+	op.Parameters = append(op.Parameters, doc.query...)
+
+	// request body
+	if doc.body != nil {
+		op.RequestBody = &requestBody{true, map[string]*mediaType{"application/json": {sp.schemaOf(reflect.TypeOf(doc.body))}}}
+	}
+
+	// responses
+	status := doc.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res := &response{Description: http.StatusText(status)}
+	if doc.resp != nil {
+		s := sp.schemaOf(reflect.TypeOf(doc.resp))
+		res.Content = map[string]*mediaType{"application/json": {s}}
+		if doc.list {
+			res.Content[formatNDJSON.contentType] = &mediaType{s.Items}
+			res.Content["text/csv"] = &mediaType{&schema{Type: "string"}}
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = res
+	if doc.cached {
+		op.Responses[strconv.Itoa(http.StatusNotModified)] = &response{Description: http.StatusText(http.StatusNotModified)}
+	}
+	op.Responses["default"] = &response{
+		Description: "Error",
+		Content:     map[string]*mediaType{"application/json": {sp.schemaOf(reflect.TypeOf(errorEnvelope{}))}},
+	}
+
+	return op
+}
+
+// schemaOf describes a json encoded go type, structs are added to the
+// component schemas and referenced by name
+func (sp *openAPI) schemaOf(t reflect.Type) *schema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := sp.schemaOf(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: sp.schemaOf(t.Elem())}
+	case reflect.Map, reflect.Interface:
+		return &schema{Type: "object"}
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.Struct:
+		name := exportedName(t.Name())
+		if _, ok := sp.Components.Schemas[name]; !ok {
+			s := &schema{Type: "object", Properties: make(map[string]*schema)}
+			sp.Components.Schemas[name] = s // add before fields for recursive types
+			sp.addProperties(s, t)
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &schema{Type: "string"}
+	}
+}
+
+func (sp *openAPI) addProperties(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			sp.addProperties(s, f.Type)
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		s.Properties[tag] = sp.schemaOf(f.Type)
+	}
+}
+
+func exportedName(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func (h *Handler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := json.NewEncoder(w).Encode(h.api); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
@@ -11,7 +11,14 @@ func (h *Handler) CreateNumPeers(w http.ResponseWriter, r *http.Request) {
 	// get request body
 	p2p := data.NewP2P()
 	if err := p2p.FromJSON(r.Body); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+
+	// validate address of api key
+	setAuditTarget(r, p2p.Addr)
+	if err := checkAddr(r.Context(), p2p.Addr); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -20,7 +27,7 @@ func (h *Handler) CreateNumPeers(w http.ResponseWriter, r *http.Request) {
 
 	// update db collection
 	if err := p2p.CreateNumPeers(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -28,19 +35,47 @@ func (h *Handler) CreateNumPeers(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetNumPeers(w http.ResponseWriter, r *http.Request) {
+	// get list params
+	q, err := h.parseListQuery(r, &data.P2Ps{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	q.pg.Match = addrMatch(r.Context()) // filter addresses of api key
+
+	// get last write time before data
+	md := data.NewModified()
+	if err := md.GetNumPeersModified(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, md.Time) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewP2Ps().StreamNumPeers(q.pg, sw)
+		})
+		return
+	}
+
 	// get data from db
 	p2p := data.NewP2Ps()
-	if err := p2p.GetNumPeers(); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := p2p.GetNumPeers(q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := p2p.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, p2p, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -51,28 +86,44 @@ func (h *Handler) GetNumPeersByAddr(w http.ResponseWriter, r *http.Request) {
 
 	// validate params
 	if len(pp) != 1 {
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := isValidAddr(pp["addr"]); err != nil {
 		// h.l.Println("error with address")
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.P2Ps{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewP2Ps().StreamNumPeersByAddr(pp["addr"], q.pg, sw)
+		})
 		return
 	}
 
 	// get data from db
 	p2p := data.NewP2Ps()
-	if err := p2p.GetNumPeersByAddr(pp["addr"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := p2p.GetNumPeersByAddr(pp["addr"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := p2p.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, p2p, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -83,31 +134,47 @@ func (h *Handler) GetNumPeersByAddrByRange(w http.ResponseWriter, r *http.Reques
 
 	// validate params
 	if len(pp) < 2 || len(pp) > 3 { // if !(2 <= len(pp) <= 3)
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := isValidAddr(pp["addr"]); err != nil {
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
 		return
 	}
 	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
-		http.Error(w, "error with times", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.P2Ps{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewP2Ps().StreamNumPeersByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg, sw)
+		})
 		return
 	}
 
 	// get data from db
 	p2p := data.NewP2Ps()
-	if err := p2p.GetNumPeersByAddrByRange(pp["addr"], pp["min"], pp["max"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := p2p.GetNumPeersByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := p2p.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, p2p, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -118,27 +185,90 @@ func (h *Handler) GetNumPeersByCluster(w http.ResponseWriter, r *http.Request) {
 
 	// validate params
 	if len(pp) != 1 {
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := areValidAddrs(pp["addrs"]); err != nil {
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.P2Ps{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	q.pg.Match = addrMatch(r.Context()) // filter addresses of api key
+
+	// get last write time before data
+	md := data.NewModified()
+	if err := md.GetNumPeersModified(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, md.Time) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	// get data from db
 	p2p := data.NewP2Ps()
-	if err := p2p.GetNumPeersByCluster(pp["addrs"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := p2p.GetNumPeersByCluster(pp["addrs"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
+
+	// encode to response format
+	if err := writeList(w, p2p, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetPeerIncidentsByAddrByRange(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// validate params
+	if len(pp) < 2 || len(pp) > 3 { // if !(2 <= len(pp) <= 3)
+		h.writeError(w, r, invalidArgument("error with request params"))
+		return
+	}
+	if err := isValidAddr(pp["addr"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.PeerIncidents{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// get data from db
+	pil := data.NewPeerIncidents()
+	if err := pil.GetPeerIncidentsByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := p2p.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, pil, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+var (
+	pageLimit    = "500"
+	pageMaxLimit = "1000"
+)
+
+type pageLimits struct {
+	limit    int
+	maxLimit int
+}
+
+func newPageLimits(l *log.Logger) *pageLimits {
+	pl := &pageLimits{500, 1000}
+
+	// override defaults if set
+	if v, err := strconv.Atoi(pageMaxLimit); err == nil && v > 0 {
+		pl.maxLimit = v
+	} else {
+		l.Printf("Error with page max limit, using default: %s\n", pageMaxLimit)
+	}
+	if v, err := strconv.Atoi(pageLimit); err == nil && v > 0 {
+		pl.limit = v
+	} else {
+		l.Printf("Error with page limit, using default: %s\n", pageLimit)
+	}
+	if pl.limit > pl.maxLimit {
+		pl.limit = pl.maxLimit
+	}
+
+	return pl
+}
+
+type listQuery struct {
+	pg     *data.Page
+	fields []string
+	format *format
+	v      interface{} // list type
+}
+
+// parseListQuery reads the page, order, fields and format params of a list request,
+// v is the list type used to validate fields
+func (h *Handler) parseListQuery(r *http.Request, v interface{}) (*listQuery, error) {
+	// get page params
+	limit, err := parseIntQuery(r, "limit", h.pl.limit)
+	if err != nil || limit < 1 {
+		return nil, invalidArgument("error with limit param")
+	}
+	if limit > h.pl.maxLimit {
+		limit = h.pl.maxLimit
+	}
+
+	pg, err := data.NewPage(limit, r.URL.Query().Get("cursor"), r.URL.Query().Get("order"))
+	if err != nil {
+		return nil, err
+	}
+
+	// get fields params
+	fields, err := parseFieldsQuery(r, v)
+	if err != nil {
+		return nil, err
+	}
+
+	// get format params
+	f, err := parseFormatQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listQuery{pg, fields, f, v}, nil
+}
+
+func setLinkHeader(w http.ResponseWriter, r *http.Request, pg *data.Page) {
+	if pg.Next == "" {
+		return
+	}
+
+	// copy request url with next cursor
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", pg.Next)
+	q.Set("limit", strconv.Itoa(pg.Limit))
+	u.RawQuery = q.Encode()
+
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", u.RequestURI()))
+}
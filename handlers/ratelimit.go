@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+var (
+	rateLimitIngest = "60/m"
+	rateLimitRead   = "600/m"
+	rateLimitAdmin  = "60/m"
+	rateLimitLogin  = "10/m"
+	rateLimitFailed = "10/m"
+)
+
+// scopeFailed names the buckets of failed authentications per ip
+const scopeFailed = "failed"
+
+// keyUsageMaxIdle is the idle time after which the usage of a key is dropped
+const keyUsageMaxIdle = 24 * time.Hour
+
+// rateSpec allows n requests per period, bursts of up to n
+type rateSpec struct {
+	n   float64
+	per time.Duration
+}
+
+// parseRateSpec reads <n>/<s|m|h>, zero disables the limit
+func parseRateSpec(s string) (*rateSpec, error) {
+	if s == "0" {
+		return nil, nil
+	}
+
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		return nil, fmt.Errorf("error missing period: %s", s)
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("error with rate: %s", s)
+	}
+
+	per, ok := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[s[i+1:]]
+	if !ok {
+		return nil, fmt.Errorf("error with period: %s", s)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	return &rateSpec{float64(n), per}, nil
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// keyUsage counts the requests of an api key since its first request after the
+// server started or its usage was last dropped
+type keyUsage struct {
+	ID          string    `json:"id"`
+	Requests    int64     `json:"requests"`
+	Limited     int64     `json:"limited"`
+	LastRequest time.Time `json:"last_request"`
+	Since       time.Time `json:"since"`
+}
+
+// rateLimiter keeps token buckets per scope of each api key & ip in memory
+type rateLimiter struct {
+	mu      sync.Mutex
+	specs   map[string]*rateSpec // by scope, nil is unlimited
+	buckets map[string]*tokenBucket
+	usage   map[string]*keyUsage
+	used    map[string]*data.KeyUse // last uses of stored keys not yet flushed
+	swept   time.Time
+}
+
+func newRateLimiter(l *log.Logger) *rateLimiter {
+	rl := &rateLimiter{
+		specs:   make(map[string]*rateSpec),
+		buckets: make(map[string]*tokenBucket),
+		usage:   make(map[string]*keyUsage),
+		used:    make(map[string]*data.KeyUse),
+		swept:   time.Now(),
+	}
+
+	// override defaults if set
+	for scope, v := range map[string][2]string{
+		data.ScopeIngest: {rateLimitIngest, "60/m"},
+		data.ScopeRead:   {rateLimitRead, "600/m"},
+		data.ScopeAdmin:  {rateLimitAdmin, "60/m"},
+		scopePublic:      {rateLimitLogin, "10/m"},
+		scopeFailed:      {rateLimitFailed, "10/m"},
+	} {
+		spec, err := parseRateSpec(v[0])
+		if err != nil {
+			l.Printf("Error with %s rate limit, using default: %s\n", scope, v[1])
+			spec, _ = parseRateSpec(v[1])
+		}
+		rl.specs[scope] = spec
+	}
+
+	return rl
+}
+
+// allow takes a token of the scope buckets of key id & ip and counts the
+// request of id, it returns the wait until a token is available if denied,
+// public routes have no key so only the ip bucket applies
+func (rl *rateLimiter) allow(scope, id, ip string) (time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.sweep(now)
+
+	u := rl.usage[id]
+	if u == nil {
+		u = &keyUsage{ID: id, Since: now.UTC()}
+		rl.usage[id] = u
+	}
+	u.Requests++
+	u.LastRequest = now.UTC()
+
+	spec := rl.specs[scope]
+	if spec == nil {
+		return 0, true
+	}
+
+	names := []string{scope + " key " + id, scope + " ip " + ip}
+	if scope == scopePublic {
+		names = names[1:]
+	}
+
+	// refill all buckets before taking from any
+	var wait time.Duration
+	var bl []*tokenBucket
+	for _, k := range names {
+		b := rl.bucket(k, spec, now)
+		if d := spec.wait(b); d > wait {
+			wait = d
+		}
+		bl = append(bl, b)
+	}
+	if wait > 0 {
+		u.Limited++
+		return wait, false
+	}
+
+	for _, b := range bl {
+		b.tokens--
+	}
+	return 0, true
+}
+
+// failed returns the wait of ip until it may authenticate again, after the
+// failed authentications of the limit
+func (rl *rateLimiter) failed(ip string) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	spec := rl.specs[scopeFailed]
+	if spec == nil {
+		return 0
+	}
+
+	now := time.Now()
+	rl.sweep(now)
+	return spec.wait(rl.bucket(scopeFailed+" ip "+ip, spec, now))
+}
+
+// fail takes a token of the failed authentications bucket of ip
+func (rl *rateLimiter) fail(ip string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	spec := rl.specs[scopeFailed]
+	if spec == nil {
+		return
+	}
+
+	b := rl.bucket(scopeFailed+" ip "+ip, spec, time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+	}
+}
+
+// bucket returns the bucket of name refilled until now
+func (rl *rateLimiter) bucket(name string, spec *rateSpec, now time.Time) *tokenBucket {
+	b := rl.buckets[name]
+	if b == nil {
+		b = &tokenBucket{spec.n, now}
+		rl.buckets[name] = b
+	}
+	b.tokens = math.Min(spec.n, b.tokens+now.Sub(b.last).Seconds()*spec.n/spec.per.Seconds())
+	b.last = now
+
+	return b
+}
+
+// wait returns the wait until b has a token
+func (spec *rateSpec) wait(b *tokenBucket) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(spec.per) / spec.n)
+}
+
+// sweep drops buckets refilled by now once a minute, they equal new buckets,
+// and the usage of keys idle for keyUsageMaxIdle
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.swept) < time.Minute {
+		return
+	}
+	rl.swept = now
+
+	for k, b := range rl.buckets {
+		spec := rl.specs[strings.SplitN(k, " ", 2)[0]]
+		if spec == nil || now.Sub(b.last) >= spec.per {
+			delete(rl.buckets, k)
+		}
+	}
+
+	for id, u := range rl.usage {
+		if now.Sub(u.LastRequest) >= keyUsageMaxIdle {
+			delete(rl.usage, id)
+		}
+	}
+}
+
+// touch keeps the last use of a stored key until the next flush
+func (rl *rateLimiter) touch(id, ip string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.used[id] = &data.KeyUse{Time: time.Now().UTC(), IP: ip}
+}
+
+// flush writes the last uses of stored keys to db
+func (rl *rateLimiter) flush() error {
+	rl.mu.Lock()
+	used := rl.used
+	rl.used = make(map[string]*data.KeyUse)
+	rl.mu.Unlock()
+
+	if len(used) == 0 {
+		return nil
+	}
+	return data.TouchAPIKeys(used)
+}
+
+// keyUsages returns a copy of the usage of all keys sorted by id
+func (rl *rateLimiter) keyUsages() []*keyUsage {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	l := []*keyUsage{}
+	for _, u := range rl.usage {
+		c := *u
+		l = append(l, &c)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].ID < l[j].ID })
+
+	return l
+}
+
+// limitRate denies requests over the rate limit of scope with 429
+func (h *Handler) limitRate(w http.ResponseWriter, r *http.Request, scope string) bool {
+	wait, ok := h.rl.allow(scope, keyID(apiKeyFrom(r)), clientIP(r))
+	if ok {
+		return false
+	}
+
+	h.writeRateLimited(w, r, wait, "error rate limit exceeded")
+	return true
+}
+
+func (h *Handler) writeRateLimited(w http.ResponseWriter, r *http.Request, wait time.Duration, msg string) {
+	// set http response headers
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	h.writeStatusError(w, r, http.StatusTooManyRequests, "rate_limited", msg)
+}
+
+func (h *Handler) GetKeyUsages(w http.ResponseWriter, r *http.Request) {
+	// get data from limiter
+	ul := h.rl.keyUsages()
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := json.NewEncoder(w).Encode(ul); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetKeyUsage(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// get data from limiter
+	var u *keyUsage
+	for _, v := range h.rl.keyUsages() {
+		if v.ID == pp["id"] {
+			u = v
+		}
+	}
+	if u == nil {
+		h.writeError(w, r, data.Errorf(data.ErrNotFound, "error no usage for key: %s", pp["id"]))
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := json.NewEncoder(w).Encode(u); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+const (
+	apiVersion  = "/v1"
+	scopePublic = "public" // routes called without api key
+)
+
+type route struct {
+	name    string
+	method  string
+	path    string   // path below api version
+	aliases []string // legacy paths
+	scope   string   // required api key scope
+	handler http.HandlerFunc
+}
+
+func (h *Handler) routes() []*route {
+	return []*route{
+		// nodes endpoints
+		{"GetHealths", http.MethodGet, "/nodes", []string{"/stats/uptimes/health"}, data.ScopeRead, h.GetHealths},
+		{"GetHealthByAddr", http.MethodGet, "/nodes/{addr}", []string{"/stats/uptimes/health/{addr}"}, data.ScopeRead, h.GetHealthByAddr},
+		{"GetUMBroadcastsByAddr", http.MethodGet, "/nodes/{addr}/broadcasts", nil, data.ScopeRead, h.GetUMBroadcastsByAddr},
+		{"GetUMBroadcastsByAddrByRange", http.MethodGet, "/nodes/{addr}/broadcasts/{min}", []string{"/stats/uptimes/broadcasts/{addr}/{min}"}, data.ScopeRead, h.GetUMBroadcastsByAddrByRange},
+		{"GetUMBroadcastsByAddrByRange", http.MethodGet, "/nodes/{addr}/broadcasts/{min}/{max}", []string{"/stats/uptimes/broadcasts/{addr}/{min}/{max}"}, data.ScopeRead, h.GetUMBroadcastsByAddrByRange},
+		{"GetNumPeersByAddr", http.MethodGet, "/nodes/{addr}/peers", nil, data.ScopeRead, h.GetNumPeersByAddr},
+		{"GetNumPeersByAddrByRange", http.MethodGet, "/nodes/{addr}/peers/{min}", []string{"/stats/uptimes/peers/{addr}/{min}"}, data.ScopeRead, h.GetNumPeersByAddrByRange},
+		{"GetNumPeersByAddrByRange", http.MethodGet, "/nodes/{addr}/peers/{min}/{max}", []string{"/stats/uptimes/peers/{addr}/{min}/{max}"}, data.ScopeRead, h.GetNumPeersByAddrByRange},
+		{"GetMissedBlocksByAddrByRange", http.MethodGet, "/nodes/{addr}/misses/{min}", []string{"/stats/blocks/misses/{addr}/{min}"}, data.ScopeRead, h.GetMissedBlocksByAddrByRange},
+		{"GetMissedBlocksByAddrByRange", http.MethodGet, "/nodes/{addr}/misses/{min}/{max}", []string{"/stats/blocks/misses/{addr}/{min}/{max}"}, data.ScopeRead, h.GetMissedBlocksByAddrByRange},
+		{"GetUptimeSummaryByAddrByRange", http.MethodGet, "/nodes/{addr}/summary/{min}", []string{"/stats/uptimes/summary/{addr}/{min}"}, data.ScopeRead, h.GetUptimeSummaryByAddrByRange},
+		{"GetUptimeSummaryByAddrByRange", http.MethodGet, "/nodes/{addr}/summary/{min}/{max}", []string{"/stats/uptimes/summary/{addr}/{min}/{max}"}, data.ScopeRead, h.GetUptimeSummaryByAddrByRange},
+		{"GetPeerIncidentsByAddrByRange", http.MethodGet, "/nodes/{addr}/incidents/{min}", []string{"/stats/uptimes/incidents/{addr}/{min}"}, data.ScopeRead, h.GetPeerIncidentsByAddrByRange},
+		{"GetPeerIncidentsByAddrByRange", http.MethodGet, "/nodes/{addr}/incidents/{min}/{max}", []string{"/stats/uptimes/incidents/{addr}/{min}/{max}"}, data.ScopeRead, h.GetPeerIncidentsByAddrByRange},
+		{"GetTimelineByAddrByRange", http.MethodGet, "/nodes/{addr}/timeline/{min}", []string{"/stats/uptimes/timeline/{addr}/{min}"}, data.ScopeRead, h.GetTimelineByAddrByRange},
+		{"GetTimelineByAddrByRange", http.MethodGet, "/nodes/{addr}/timeline/{min}/{max}", []string{"/stats/uptimes/timeline/{addr}/{min}/{max}"}, data.ScopeRead, h.GetTimelineByAddrByRange},
+
+		// broadcasts endpoints
+		{"CreateUMBroadcast", http.MethodPost, "/broadcasts", []string{"/stats/uptimes/broadcasts"}, data.ScopeIngest, h.CreateUMBroadcast},
+		{"GetUMBroadcasts", http.MethodGet, "/broadcasts", []string{"/stats/uptimes/broadcasts"}, data.ScopeRead, h.GetUMBroadcasts}, // select * query
+
+		// peers endpoints
+		{"CreateNumPeers", http.MethodPost, "/peers", []string{"/stats/uptimes/peers"}, data.ScopeIngest, h.CreateNumPeers},
+		{"GetNumPeers", http.MethodGet, "/peers", []string{"/stats/uptimes/peers"}, data.ScopeRead, h.GetNumPeers},
+
+		// heartbeats endpoints
+		{"CreateHeartbeat", http.MethodPost, "/heartbeats", []string{"/stats/uptimes/heartbeats"}, data.ScopeIngest, h.CreateHeartbeat},
+
+		// clusters endpoints
+		{"GetUMBroadcastsByCluster", http.MethodGet, "/clusters/{addrs}/broadcasts", []string{"/clusters/uptimes/broadcasts/{addrs}"}, data.ScopeRead, h.GetUMBroadcastsByCluster},
+		{"GetNumPeersByCluster", http.MethodGet, "/clusters/{addrs}/peers", []string{"/clusters/uptimes/peers/{addrs}"}, data.ScopeRead, h.GetNumPeersByCluster},
+
+		// blocks endpoints
+		{"CreateBlock", http.MethodPost, "/blocks", []string{"/stats/blocks"}, data.ScopeIngest, h.CreateBlock},
+		{"GetBlocks", http.MethodGet, "/blocks", nil, data.ScopeRead, h.GetBlocks},
+		{"GetBlocksByRange", http.MethodGet, "/blocks/{min}", []string{"/stats/blocks/{min}"}, data.ScopeRead, h.GetBlocksByRange},
+		{"GetBlocksByRange", http.MethodGet, "/blocks/{min}/{max}", []string{"/stats/blocks/{min}/{max}"}, data.ScopeRead, h.GetBlocksByRange},
+
+		// keys endpoints
+		{"CreateAPIKey", http.MethodPost, "/keys", nil, data.ScopeAdmin, h.CreateAPIKey},
+		{"GetAPIKeys", http.MethodGet, "/keys", nil, data.ScopeAdmin, h.GetAPIKeys},
+		{"GetAPIKey", http.MethodGet, "/keys/{id}", nil, data.ScopeAdmin, h.GetAPIKey},
+		{"RevokeAPIKey", http.MethodDelete, "/keys/{id}", nil, data.ScopeAdmin, h.RevokeAPIKey},
+		{"RotateAPIKey", http.MethodPost, "/keys/{id}/rotate", nil, data.ScopeAdmin, h.RotateAPIKey},
+		{"GetKeyUsage", http.MethodGet, "/keys/{id}/usage", nil, data.ScopeAdmin, h.GetKeyUsage},
+
+		// shares endpoints
+		{"CreateShare", http.MethodPost, "/shares", nil, data.ScopeRead, h.CreateShare},
+		{"GetShares", http.MethodGet, "/shares", nil, data.ScopeAdmin, h.GetShares},
+		{"RevokeShare", http.MethodDelete, "/shares/{id}", nil, data.ScopeRead, h.RevokeShare},
+
+		// usage endpoints
+		{"GetKeyUsages", http.MethodGet, "/usage", nil, data.ScopeAdmin, h.GetKeyUsages},
+
+		// audit endpoints
+		{"GetAuditEntries", http.MethodGet, "/audit", nil, data.ScopeAdmin, h.GetAuditEntries},
+
+		// sessions endpoints
+		{"Login", http.MethodPost, "/login", nil, scopePublic, h.Login},
+		{"Logout", http.MethodPost, "/logout", nil, data.ScopeRead, h.Logout},
+		{"GetSession", http.MethodGet, "/session", nil, data.ScopeRead, h.GetSession},
+
+		// users endpoints
+		{"CreateUser", http.MethodPost, "/users", nil, data.ScopeAdmin, h.CreateUser},
+		{"GetUsers", http.MethodGet, "/users", nil, data.ScopeAdmin, h.GetUsers},
+		{"GetUser", http.MethodGet, "/users/{name}", nil, data.ScopeAdmin, h.GetUser},
+		{"DisableUser", http.MethodDelete, "/users/{name}", nil, data.ScopeAdmin, h.DisableUser},
+	}
+}
+
+// rootRoutes are served without api version
+func (h *Handler) rootRoutes() []*route {
+	return []*route{
+		{"GetOpenAPI", http.MethodGet, openapiPath, nil, data.ScopeRead, h.GetOpenAPI},
+		{"GraphQL", http.MethodGet, graphqlPath, nil, data.ScopeRead, h.GraphQL},
+		{"GraphQL", http.MethodPost, graphqlPath, nil, data.ScopeRead, h.GraphQL},
+	}
+}
+
+// authorize wraps the handler of a route with the checks of its api key and
+// records its calls to the audit log, public routes are only rate limited
+func (h *Handler) authorize(rt *route) http.HandlerFunc {
+	if rt.scope == scopePublic {
+		return h.audit(rt, h.requireScope(rt.scope, rt.handler))
+	}
+
+	return h.audit(rt, h.requireRoute(rt.name, h.requireScope(rt.scope, h.requireAddr(rt.handler))))
+}
+
+// publicPaths are the paths of the public routes, requests to them are not
+// authenticated
+func publicPaths(rts []*route) map[string]bool {
+	m := make(map[string]bool)
+	for _, rt := range rts {
+		if rt.scope != scopePublic {
+			continue
+		}
+
+		m[apiVersion+rt.path] = true
+		for _, p := range rt.aliases {
+			m[p] = true
+		}
+	}
+
+	return m
+}
+
+// NewRouter registers the versioned api routes and their legacy aliases
+func NewRouter(h *Handler) *mux.Router {
+	sm := mux.NewRouter()
+	sm.NotFoundHandler = http.HandlerFunc(h.NotFound)
+	sm.MethodNotAllowedHandler = http.HandlerFunc(h.MethodNotAllowed)
+
+	// validate requests if set
+	if v, err := strconv.ParseBool(openapiValidate); err != nil {
+		h.l.Printf("Error with openapi validate, using default: false\n")
+	} else if v {
+		sm.Use(h.MiddlewareValidate)
+	}
+
+	// versioned endpoints
+	v1 := sm.PathPrefix(apiVersion).Subrouter()
+	for _, rt := range h.routes() {
+		v1.HandleFunc(rt.path, h.authorize(rt)).Methods(rt.method)
+	}
+
+	// legacy endpoints
+	for _, rt := range h.routes() {
+		for _, p := range rt.aliases {
+			sm.HandleFunc(p, h.authorize(rt)).Methods(rt.method)
+		}
+	}
+
+	// unversioned endpoints
+	for _, rt := range h.rootRoutes() {
+		sm.HandleFunc(rt.path, h.authorize(rt)).Methods(rt.method)
+	}
+
+	return sm
+}
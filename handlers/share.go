@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+var (
+	shareMaxAge = "720h"
+
+	shareTokenHdr   = "X-Share-Token"
+	shareTokenQuery = "share"
+)
+
+func newShareMaxAge(l *log.Logger) time.Duration {
+	// override default if set
+	v, err := time.ParseDuration(shareMaxAge)
+	if err != nil || v <= 0 {
+		l.Printf("Error with share max age, using default: 720h\n")
+		return 720 * time.Hour
+	}
+
+	return v
+}
+
+// shareToken reads the share token of a request, MiddlewareShareToken moves
+// the query param to the header
+func shareToken(r *http.Request) string {
+	return r.Header.Get(shareTokenHdr)
+}
+
+// MiddlewareShareToken moves the share token of the query to the header, so
+// it does not appear in access logs of the url
+func (h *Handler) MiddlewareShareToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.RawQuery, shareTokenQuery+"=") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// drop the share params, keeping the order of the others
+		var t string
+		var qs []string
+		for _, p := range strings.Split(r.URL.RawQuery, "&") {
+			k, v := p, ""
+			if i := strings.IndexByte(p, '='); i >= 0 {
+				k, v = p[:i], p[i+1:]
+			}
+			if k != shareTokenQuery {
+				qs = append(qs, p)
+				continue
+			}
+			if t == "" {
+				t, _ = url.QueryUnescape(v)
+			}
+		}
+
+		r = r.Clone(r.Context())
+		r.URL.RawQuery = strings.Join(qs, "&")
+		r.RequestURI = r.URL.RequestURI()
+		if r.Header.Get(shareTokenHdr) == "" && t != "" {
+			r.Header.Set(shareTokenHdr, t)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateShare reads the read only key of a share token, shares without
+// routes may call all read routes
+func (h *Handler) authenticateShare(token string) (*data.APIKey, error) {
+	sh := data.NewShare()
+	if err := sh.GetShareByToken(token); err != nil {
+		return nil, err
+	}
+
+	k := sh.APIKey()
+	if len(k.Routes) == 0 {
+		k.Routes = h.shareRoutes()
+	}
+
+	return k, nil
+}
+
+// shareRoutes are the names of the get routes of the read scope a share may
+// grant, graphql queries by post share the name of the get route
+func (h *Handler) shareRoutes() []string {
+	var l []string
+	for _, rt := range append(h.routes(), h.rootRoutes()...) {
+		if rt.scope == data.ScopeRead && rt.method == http.MethodGet && indexOf(l, rt.name) < 0 {
+			l = append(l, rt.name)
+		}
+	}
+
+	return l
+}
+
+func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	// get request body
+	sh := data.NewShare()
+	if err := sh.FromJSON(r.Body); err != nil {
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+
+	// validate share within api key
+	k := apiKeyFrom(r)
+	if k.Name == data.ShareKeyName {
+		h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error shares not allowed for share"))
+		return
+	}
+	for _, a := range sh.Addresses {
+		if err := checkAddr(r.Context(), a); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+	}
+	routes := h.shareRoutes()
+	for _, v := range sh.Routes {
+		if indexOf(routes, v) < 0 {
+			h.writeError(w, r, invalidArgument("error with share route: "+v))
+			return
+		}
+		if !k.AllowsRoute(v) {
+			h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error route not allowed: %s", v))
+			return
+		}
+	}
+	// shares without routes get the read routes of the key, not all of them
+	if len(sh.Routes) == 0 && len(k.Routes) > 0 {
+		for _, v := range k.Routes {
+			if indexOf(routes, v) >= 0 {
+				sh.Routes = append(sh.Routes, v)
+			}
+		}
+		if len(sh.Routes) == 0 {
+			h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error no share routes allowed for api key"))
+			return
+		}
+	}
+	if sh.ExpiresAt.After(time.Now().Add(h.sm)) {
+		h.writeError(w, r, invalidArgument("error share expiry beyond "+h.sm.String()))
+		return
+	}
+
+	// update db collection
+	token, err := sh.CreateShare(keyID(k))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	setAuditTarget(r, sh.ID)
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+
+	// encode to json byte array
+	st := &data.ShareToken{Share: *sh, Token: token}
+	if err := st.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetShares(w http.ResponseWriter, r *http.Request) {
+	// get data from db
+	shl := data.NewShares()
+	if err := shl.GetShares(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := shl.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// validate share of api key unless admin
+	k := apiKeyFrom(r)
+	if !k.HasScope(data.ScopeAdmin) {
+		sh := data.NewShare()
+		if err := sh.GetShare(pp["id"]); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		if k.Name == data.ShareKeyName || sh.CreatedBy != keyID(k) {
+			h.writeError(w, r, data.Errorf(data.ErrPermissionDenied, "error share of other key: %s", pp["id"]))
+			return
+		}
+	}
+
+	// update db collection
+	sh := data.NewShare()
+	if err := sh.RevokeShare(pp["id"]); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := sh.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+func TestHandlerCreateShare(t *testing.T) {
+	h := NewHandler(log.New(io.Discard, "", 0))
+
+	// test routes beyond the routes of the key denied
+	for _, v := range []struct {
+		routes []string
+		body   string
+	}{
+		{[]string{"GetHealths"}, `{"addresses":[],"routes":["GetHealthByAddr"]}`},
+		{[]string{"CreateShare"}, `{"addresses":[]}`},
+	} {
+		k := &data.APIKey{ID: "0a1b2c3d", Name: "dashboard", Scopes: []string{data.ScopeRead}, Routes: v.routes}
+		r := httptest.NewRequest(http.MethodPost, "/v1/shares", strings.NewReader(v.body))
+		r = r.WithContext(context.WithValue(r.Context(), ctxAPIKey, k))
+		w := httptest.NewRecorder()
+
+		h.CreateShare(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("handlers.CreateShare(%s) with routes %v returned: %v, wanted: %v", v.body, v.routes, w.Code, http.StatusForbidden)
+		}
+	}
+}
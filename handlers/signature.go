@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+var (
+	signatureSkew     = "5m"
+	signatureRequired = "false"
+	signatureMaxBody  = "1048576"
+	nodeSignatures    = "false"
+	nodeAddresses     = "" // comma separated address patterns of nodes that may sign
+
+	keyIDHdr     = "X-Api-Key-Id"
+	nodeAddrHdr  = "X-Node-Address"
+	timestampHdr = "X-Signature-Timestamp"
+	nonceHdr     = "X-Signature-Nonce"
+	signatureHdr = "X-Signature"
+	nonceRe      = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+)
+
+type signatureOptions struct {
+	skew     time.Duration // max distance of signed timestamps from now
+	required bool          // ingest requests must be signed
+	maxBody  int64         // max bytes of signed request bodies
+	nodes    []string      // address patterns of edge nodes that may sign ingest requests with their own key
+}
+
+func newSignatureOptions(l *log.Logger) *signatureOptions {
+	so := &signatureOptions{skew: 5 * time.Minute, maxBody: 1 << 20}
+
+	// override defaults if set
+	if v, err := time.ParseDuration(signatureSkew); err == nil && v > 0 {
+		so.skew = v
+	} else {
+		l.Printf("Error with signature skew, using default: 5m\n")
+	}
+	if v, err := strconv.ParseBool(signatureRequired); err == nil {
+		so.required = v
+	} else {
+		l.Printf("Error with signature required, using default: false\n")
+	}
+	if v, err := strconv.ParseInt(signatureMaxBody, 10, 64); err == nil && v > 0 {
+		so.maxBody = v
+	} else {
+		l.Printf("Error with signature max body, using default: 1048576\n")
+	}
+	if v, err := strconv.ParseBool(nodeSignatures); err != nil {
+		l.Printf("Error with node signatures, using default: false\n")
+	} else if v && nodeAddresses == "" {
+		l.Printf("Error with node signatures, no node addresses set, using default: false\n")
+	} else if v {
+		so.nodes = strings.Split(nodeAddresses, ",")
+	}
+
+	return so
+}
+
+// isSigned reports whether a request carries a signature instead of a token
+func isSigned(r *http.Request) bool {
+	return r.Header.Get(signatureHdr) != ""
+}
+
+// authenticateSigned reads the key of a signed request, the signature covers
+// method, uri, timestamp, nonce and the sha256 of the body, requests with a
+// node address are signed by the key of that edge node, bodies over the max
+// size are denied before hashing
+func (h *Handler) authenticateSigned(w http.ResponseWriter, r *http.Request) (*data.APIKey, error) {
+	// validate timestamp & nonce
+	sec, err := strconv.ParseInt(r.Header.Get(timestampHdr), 10, 64)
+	if err != nil {
+		return nil, data.Errorf(data.ErrPermissionDenied, "error with signature timestamp")
+	}
+	ts := time.Unix(sec, 0)
+	if d := time.Since(ts); d > h.so.skew || d < -h.so.skew {
+		return nil, data.Errorf(data.ErrPermissionDenied, "error signature timestamp outside %s", h.so.skew)
+	}
+	nonce := r.Header.Get(nonceHdr)
+	if !nonceRe.MatchString(nonce) {
+		return nil, data.Errorf(data.ErrPermissionDenied, "error with signature nonce")
+	}
+
+	// read body & restore for next handler
+	b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, h.so.maxBody))
+	if err != nil {
+		return nil, invalidArgument(fmt.Sprintf("error reading request body of at most %d bytes", h.so.maxBody))
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	// verify signature of node or api key before recording the nonce
+	k := data.NewAPIKey()
+	msg := signatureMessage(r.Method, r.URL.RequestURI(), r.Header.Get(timestampHdr), nonce, b)
+	if addr := r.Header.Get(nodeAddrHdr); addr != "" {
+		if len(h.so.nodes) == 0 {
+			return nil, data.Errorf(data.ErrPermissionDenied, "error node signatures disabled")
+		}
+		if err := k.GetAPIKeyByNodeSignature(addr, msg, r.Header.Get(signatureHdr), h.so.nodes); err != nil {
+			return nil, err
+		}
+	} else if err := k.GetAPIKeyBySignature(r.Header.Get(keyIDHdr), msg, r.Header.Get(signatureHdr)); err != nil {
+		return nil, err
+	}
+	if err := k.UseNonce(nonce, ts, h.so.skew); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+func signatureMessage(method, uri, ts, nonce string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return []byte(method + "\n" + uri + "\n" + ts + "\n" + nonce + "\n" + hex.EncodeToString(sum[:]))
+}
+
+func signedFrom(ctx context.Context) bool {
+	v, _ := ctx.Value(ctxSigned).(bool)
+	return v
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/edgestats/edgestats-server/data"
+)
+
+// listStream writes the raw json records of a db scan as a json array, ndjson
+// lines or csv rows without building the list in memory
+type listStream struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	q       *listQuery
+	csv     *data.CSVWriter
+	n       int
+	started bool
+}
+
+// streams reports whether the list query can be written from raw records,
+// csv rows select their fields themselves
+func (q *listQuery) streams() bool {
+	return q.format == formatCSV || (len(q.fields) == 0 && (q.format == formatJSON || q.format == formatNDJSON))
+}
+
+func (ls *listStream) Head(pg *data.Page) error {
+	// set http response headers
+	ls.w.Header().Set("Content-Type", ls.q.format.contentType)
+	setLinkHeader(ls.w, ls.r, pg)
+
+	ls.started = true
+	if ls.csv != nil {
+		return ls.csv.WriteHeader()
+	}
+	if ls.q.format == formatJSON {
+		_, err := ls.w.Write([]byte{'['})
+		return err
+	}
+
+	return nil
+}
+
+func (ls *listStream) Record(b []byte) error {
+	if ls.csv != nil {
+		return ls.csv.Record(b)
+	}
+
+	buf := make([]byte, 0, len(b)+1)
+	if ls.q.format == formatJSON && ls.n > 0 {
+		buf = append(buf, ',')
+	}
+	buf = append(buf, bytes.TrimSpace(b)...)
+	if ls.q.format == formatNDJSON {
+		buf = append(buf, '\n')
+	}
+	ls.n++
+
+	_, err := ls.w.Write(buf)
+	return err
+}
+
+func (ls *listStream) close() error {
+	if ls.csv != nil {
+		return ls.csv.Flush()
+	}
+	if ls.q.format != formatJSON {
+		return nil
+	}
+
+	_, err := ls.w.Write([]byte("]\n"))
+	return err
+}
+
+// streamList writes the records read by stream, errors before the first
+// byte is written get an error response
+func (h *Handler) streamList(w http.ResponseWriter, r *http.Request, q *listQuery, stream func(sw data.StreamWriter) error) {
+	ls := &listStream{w: w, r: r, q: q}
+	if q.format == formatCSV {
+		c, err := data.NewCSVWriter(w, q.v, q.fields)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		ls.csv = c
+	}
+
+	err := stream(ls)
+	if err == nil {
+		err = ls.close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !ls.started {
+		h.writeError(w, r, err)
+		return
+	}
+	h.l.Printf("Error encoding response: %s\n", err)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) GetTimelineByAddrByRange(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// validate params
+	if len(pp) < 2 || len(pp) > 3 { // if !(2 <= len(pp) <= 3)
+		h.writeError(w, r, invalidArgument("error with request params"))
+		return
+	}
+	if err := isValidAddr(pp["addr"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.Timeline{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// get data from db
+	tl := data.NewTimeline()
+	if err := tl.GetTimelineByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
+
+	// encode to response format
+	if err := writeList(w, tl, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
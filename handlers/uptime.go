@@ -11,7 +11,14 @@ func (h *Handler) CreateUMBroadcast(w http.ResponseWriter, r *http.Request) {
 	// get request body
 	um := data.NewUMBroadcast()
 	if err := um.FromJSON(r.Body); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+
+	// validate address of api key
+	setAuditTarget(r, um.Addr)
+	if err := checkAddr(r.Context(), um.Addr); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -20,7 +27,7 @@ func (h *Handler) CreateUMBroadcast(w http.ResponseWriter, r *http.Request) {
 
 	// update db collection
 	if err := um.CreateUMBroadcast(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -28,19 +35,47 @@ func (h *Handler) CreateUMBroadcast(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetUMBroadcasts(w http.ResponseWriter, r *http.Request) {
+	// get list params
+	q, err := h.parseListQuery(r, &data.UMBroadcasts{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	q.pg.Match = addrMatch(r.Context()) // filter addresses of api key
+
+	// get last write time before data
+	md := data.NewModified()
+	if err := md.GetUMBroadcastsModified(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, md.Time) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewUMBroadcasts().StreamUMBroadcasts(q.pg, sw)
+		})
+		return
+	}
+
 	// get data from db
 	um := data.NewUMBroadcasts()
-	if err := um.GetUMBroadcasts(); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := um.GetUMBroadcasts(q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := um.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, um, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -51,27 +86,43 @@ func (h *Handler) GetUMBroadcastsByAddr(w http.ResponseWriter, r *http.Request)
 
 	// validate params
 	if len(pp) != 1 {
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := isValidAddr(pp["addr"]); err != nil {
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.UMBroadcasts{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewUMBroadcasts().StreamUMBroadcastsByAddr(pp["addr"], q.pg, sw)
+		})
 		return
 	}
 
 	// get data from db
 	um := data.NewUMBroadcasts()
-	if err := um.GetUMBroadcastsByAddr(pp["addr"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := um.GetUMBroadcastsByAddr(pp["addr"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := um.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, um, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -82,31 +133,47 @@ func (h *Handler) GetUMBroadcastsByAddrByRange(w http.ResponseWriter, r *http.Re
 
 	// validate params
 	if len(pp) < 2 || len(pp) > 3 { // if !(2 <= len(pp) <= 3)
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := isValidAddr(pp["addr"]); err != nil {
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
 		return
 	}
 	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
-		http.Error(w, "error with times", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.UMBroadcasts{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// stream data from db if possible
+	if q.streams() {
+		h.streamList(w, r, q, func(sw data.StreamWriter) error {
+			return data.NewUMBroadcasts().StreamUMBroadcastsByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg, sw)
+		})
 		return
 	}
 
 	// get data from db
 	um := data.NewUMBroadcasts()
-	if err := um.GetUMBroadcastsByAddrByRange(pp["addr"], pp["min"], pp["max"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := um.GetUMBroadcastsByAddrByRange(pp["addr"], pp["min"], pp["max"], q.pg); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	// set http response headers
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
 
-	// encode to json byte array
-	if err := um.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// encode to response format
+	if err := writeList(w, um, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
 		return
 	}
 }
@@ -117,18 +184,80 @@ func (h *Handler) GetUMBroadcastsByCluster(w http.ResponseWriter, r *http.Reques
 
 	// validate params
 	if len(pp) != 1 {
-		http.Error(w, "error with request params", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with request params"))
 		return
 	}
 	if err := areValidAddrs(pp["addrs"]); err != nil {
-		http.Error(w, "error with address", http.StatusBadRequest)
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+
+	// get list params
+	q, err := h.parseListQuery(r, &data.UMBroadcasts{})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	q.pg.Match = addrMatch(r.Context()) // filter addresses of api key
+
+	// get last write time before data
+	md := data.NewModified()
+	if err := md.GetUMBroadcastsModified(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if notModified(w, r, md.Time) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	// get data from db
 	um := data.NewUMBroadcasts()
-	if err := um.GetUMBroadcastsByCluster(pp["addrs"]); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if err := um.GetUMBroadcastsByCluster(pp["addrs"], q.pg); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", q.format.contentType)
+	setLinkHeader(w, r, q.pg)
+
+	// encode to response format
+	if err := writeList(w, um, q); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetUptimeSummaryByAddrByRange(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// validate params
+	if len(pp) < 2 || len(pp) > 3 { // if !(2 <= len(pp) <= 3)
+		h.writeError(w, r, invalidArgument("error with request params"))
+		return
+	}
+	if err := isValidAddr(pp["addr"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with address"))
+		return
+	}
+	if err := areValidTimes(pp["min"], pp["max"]); err != nil {
+		h.writeError(w, r, invalidArgument("error with times"))
+		return
+	}
+
+	// get query params
+	daily, err := parseBoolQuery(r, "daily")
+	if err != nil {
+		h.writeError(w, r, invalidArgument("error with daily param"))
+		return
+	}
+
+	// get data from db
+	us := data.NewUptimeSummary()
+	if err := us.GetUptimeSummaryByAddrByRange(pp["addr"], pp["min"], pp["max"], daily); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -136,8 +265,48 @@ func (h *Handler) GetUMBroadcastsByCluster(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 
 	// encode to json byte array
-	if err := um.ToJSON(w); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := us.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) CreateHeartbeat(w http.ResponseWriter, r *http.Request) {
+	// get request body
+	hb := data.NewHeartbeat()
+	if err := hb.FromJSON(r.Body); err != nil {
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+
+	// validate address of api key
+	setAuditTarget(r, hb.Addr)
+	if err := checkAddr(r.Context(), hb.Addr); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// update db collections
+	if err := hb.CreateHeartbeat(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// get node status from db
+	hs := data.NewHealth()
+	if err := hs.GetHealthByAddr(hb.Addr, h.th); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	// encode to json byte array
+	if err := hs.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding heartbeat status: %s\n", err)
 		return
 	}
 }
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edgestats/edgestats-server/data"
+	"github.com/gorilla/mux"
+)
+
+var (
+	sessionMaxAge = "12h"
+	sessionCookie = "edgestats_session"
+)
+
+func newSessionMaxAge(l *log.Logger) time.Duration {
+	// override default if set
+	v, err := time.ParseDuration(sessionMaxAge)
+	if err != nil || v <= 0 {
+		l.Printf("Error with session max age, using default: 12h\n")
+		return 12 * time.Hour
+	}
+
+	return v
+}
+
+// loginRequest is the body of a login
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// sessionToken reads the session token of a request from the bearer
+// authorization header or the session cookie
+func sessionToken(r *http.Request) string {
+	if v := r.Header.Get("Authorization"); strings.HasPrefix(v, "Bearer ") {
+		return strings.TrimPrefix(v, "Bearer ")
+	}
+
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// authenticateSession reads the key of the user role of a session token
+func authenticateSession(token string) (*data.APIKey, error) {
+	u, err := data.NewSession().GetSessionByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.APIKey(), nil
+}
+
+// setSessionCookie sets the session cookie to token until exp, an empty
+// token clears the cookie
+func setSessionCookie(w http.ResponseWriter, r *http.Request, token string, exp time.Time) {
+	c := &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  exp,
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if token == "" {
+		c.MaxAge = -1
+	}
+
+	http.SetCookie(w, c)
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	// get request body
+	lr := &loginRequest{}
+	if err := json.NewDecoder(r.Body).Decode(lr); err != nil {
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+	setAuditTarget(r, lr.Username)
+
+	// update db collection
+	s := data.NewSession()
+	token, err := s.Login(lr.Username, lr.Password, h.sa)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	setSessionCookie(w, r, token, s.ExpiresAt)
+	w.WriteHeader(http.StatusCreated)
+
+	// encode to json byte array
+	st := &data.SessionToken{Session: *s, Token: token}
+	if err := st.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	// validate params
+	token := sessionToken(r)
+	if token == "" {
+		h.writeError(w, r, invalidArgument("error no session"))
+		return
+	}
+
+	// update db collection
+	s := data.NewSession()
+	if err := s.Logout(token); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	setSessionCookie(w, r, "", time.Unix(0, 0))
+
+	// encode to json byte array
+	if err := s.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetSession(w http.ResponseWriter, r *http.Request) {
+	// validate params
+	token := sessionToken(r)
+	if token == "" {
+		h.writeError(w, r, data.Errorf(data.ErrNotFound, "error no session"))
+		return
+	}
+
+	// get data from db
+	s := data.NewSession()
+	if _, err := s.GetSessionByToken(token); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	// encode to json byte array
+	if err := s.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	// get request body
+	u := data.NewUser()
+	if err := u.FromJSON(r.Body); err != nil {
+		h.writeError(w, r, invalidArgument(err.Error()))
+		return
+	}
+	setAuditTarget(r, u.Username)
+
+	// update db collection
+	if err := u.CreateUser(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	// encode to json byte array
+	if err := u.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	// get data from db
+	ul := data.NewUsers()
+	if err := ul.GetUsers(); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := ul.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// get data from db
+	u := data.NewUser()
+	if err := u.GetUser(pp["name"]); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := u.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
+
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	// get path params
+	pp := mux.Vars(r)
+
+	// update db collection
+	u := data.NewUser()
+	if err := u.DisableUser(pp["name"]); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// set http response headers
+	w.Header().Set("Content-Type", "application/json")
+
+	// encode to json byte array
+	if err := u.ToJSON(w); err != nil {
+		h.l.Printf("Error encoding response: %s\n", err)
+		return
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// MiddlewareValidate rejects requests not matching the openapi document
+func (h *Handler) MiddlewareValidate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// find operation of matched route
+		op := h.api.operation(r)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// validate request
+		if err := h.api.validateRequest(r, op); err != nil {
+			h.writeError(w, r, invalidArgument(err.Error()))
+			return
+		}
+
+		// next handler
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (sp *openAPI) operation(r *http.Request) *operation {
+	rt := mux.CurrentRoute(r)
+	if rt == nil {
+		return nil
+	}
+	tpl, err := rt.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+
+	return sp.Paths[tpl][strings.ToLower(r.Method)]
+}
+
+func (sp *openAPI) validateRequest(r *http.Request, op *operation) error {
+	// validate query params
+	q := r.URL.Query()
+	for _, p := range op.Parameters {
+		if p.In != "query" || q.Get(p.Name) == "" {
+			continue
+		}
+		if err := validateParam(q.Get(p.Name), p.Schema); err != nil {
+			return fmt.Errorf("error with %s param: %s", p.Name, err)
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+
+	// read body & restore for next handler
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("error reading request body: %s", err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	// validate request body
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("error with request body: %s", err)
+	}
+	if err := sp.validateValue(v, op.RequestBody.Content["application/json"].Schema, "body"); err != nil {
+		return fmt.Errorf("error with request body: %s", err)
+	}
+
+	return nil
+}
+
+func validateParam(v string, s *schema) error {
+	switch s.Type {
+	case "integer":
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			return fmt.Errorf("%d is less than %d", n, *s.Minimum)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+	}
+
+	if len(s.Enum) > 0 && indexOf(s.Enum, v) < 0 {
+		return fmt.Errorf("%q is not one of %s", v, strings.Join(s.Enum, ", "))
+	}
+
+	return nil
+}
+
+// validateValue checks a decoded json value against the schema, unknown
+// object properties are allowed
+func (sp *openAPI) validateValue(v interface{}, s *schema, path string) error {
+	// referenced schemas may be null
+	if v == nil {
+		if s.Nullable || s.Ref != "" {
+			return nil
+		}
+		return fmt.Errorf("%s is null", path)
+	}
+	if s.Ref != "" {
+		s = sp.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+
+	switch s.Type {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", path)
+		}
+		for k, ps := range s.Properties {
+			if pv, ok := m[k]; ok {
+				if err := sp.validateValue(pv, ps, path+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		l, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an array", path)
+		}
+		for i, iv := range l {
+			if err := sp.validateValue(iv, s.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s is not an integer", path)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s is not a number", path)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s is not a boolean", path)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s is not a string", path)
+		}
+		if _, err := time.Parse(time.RFC3339, str); s.Format == "date-time" && err != nil {
+			return fmt.Errorf("%s is not an RFC3339 time", path)
+		}
+	}
+
+	return nil
+}
+
+func indexOf(l []string, s string) int {
+	for i, v := range l {
+		if v == s {
+			return i
+		}
+	}
+
+	return -1
+}